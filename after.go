@@ -0,0 +1,61 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTransitionLoop is returned when after-transition hooks chain more transitions than the machine allows
+var ErrTransitionLoop = errors.New("too many chained transitions")
+
+// how many transitions after-transition hooks may chain by default
+const defaultMaxChainDepth = 10
+
+// register a hook that runs after every successful transition, e.g. to move straight on from
+// "Validated" to "Processing" when some condition holds. the hook may call Transition (or any other
+// transition method) on the machine itself, and hooks run in the order they were registered.
+//
+// to stop hooks from chaining transitions forever, each transition a hook triggers counts as one level
+// deeper than the one that triggered it, and a transition beyond the maximum depth (10 unless changed
+// with SetMaxChainDepth) is rejected with ErrTransitionLoop. hooks run on the goroutine that made the
+// transition, and the depth is tracked per machine, so while a hook is running, transitions started
+// on other goroutines count towards its depth too
+func (sm *StateMachine) AfterTransition(fn func(sm *StateMachine, from, to State)) {
+	sm.afterHooks = append(sm.afterHooks, fn)
+}
+
+// set how many levels of transitions after-transition hooks may chain before ErrTransitionLoop is returned
+func (sm *StateMachine) SetMaxChainDepth(depth int) {
+	sm.maxChainDepth = depth
+}
+
+// reject a transition started by an after-transition hook when hooks have already chained too many
+func (sm *StateMachine) checkChainDepth(from, to State) error {
+	sm.mu.RLock()
+	depth := sm.chainDepth
+	sm.mu.RUnlock()
+
+	if depth > sm.maxChainDepth {
+		return fmt.Errorf("%w: more than %d levels deep, from %s to %s", ErrTransitionLoop, sm.maxChainDepth, stateName(from), stateName(to))
+	}
+	return nil
+}
+
+// run the after-transition hooks for a successful transition, one level deeper than the transition itself
+func (sm *StateMachine) runAfterHooks(from, to State) {
+	if len(sm.afterHooks) == 0 {
+		return
+	}
+
+	sm.adjustChainDepth(1)
+	defer sm.adjustChainDepth(-1)
+	for _, hook := range sm.afterHooks {
+		hook(sm, from, to)
+	}
+}
+
+func (sm *StateMachine) adjustChainDepth(delta int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.chainDepth += delta
+}
@@ -0,0 +1,80 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestAfterTransition(t *testing.T) {
+	var seen []string
+	sm := statemachine.NewStateMachine("Created")
+	sm.AddTransitions("Created", "Paid")
+	sm.AddTransitions("Paid", "Shipped")
+	sm.AfterTransition(func(sm *statemachine.StateMachine, from, to statemachine.State) {
+		seen = append(seen, from.(string)+"->"+to.(string))
+		if to == "Paid" {
+			sm.Transition("Shipped")
+		}
+	})
+
+	if err := sm.Transition("Paid"); err != nil {
+		t.Fatal(err)
+	}
+	if got := sm.CurrentState(); got != "Shipped" {
+		t.Fatalf("state = %v, want Shipped", got)
+	}
+	if want := []string{"Created->Paid", "Paid->Shipped"}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("hooks saw %v, want %v", seen, want)
+	}
+
+	seen = nil
+	sm.Transition("Created") // rejected, so no hook runs
+	if len(seen) != 0 {
+		t.Fatalf("hooks ran after a rejected transition: %v", seen)
+	}
+}
+
+func TestAfterTransitionLoop(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxDepth  int
+		wantSteps int
+	}{
+		{name: "default depth", wantSteps: 11},
+		{name: "custom depth", maxDepth: 3, wantSteps: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lastErr error
+			sm := statemachine.NewStateMachine("Ping")
+			sm.AddTransitions("Ping", "Pong")
+			sm.AddTransitions("Pong", "Ping")
+			if tt.maxDepth > 0 {
+				sm.SetMaxChainDepth(tt.maxDepth)
+			}
+			sm.AfterTransition(func(sm *statemachine.StateMachine, from, to statemachine.State) {
+				next := statemachine.State("Ping")
+				if to == "Ping" {
+					next = "Pong"
+				}
+				if err := sm.Transition(next); err != nil {
+					lastErr = err
+				}
+			})
+
+			if err := sm.Transition("Pong"); err != nil {
+				t.Fatal(err)
+			}
+			if !errors.Is(lastErr, statemachine.ErrTransitionLoop) {
+				t.Fatalf("chained transition error = %v, want ErrTransitionLoop", lastErr)
+			}
+			if got := len(sm.History()); got != tt.wantSteps {
+				t.Fatalf("made %d transitions, want %d", got, tt.wantSteps)
+			}
+		})
+	}
+}
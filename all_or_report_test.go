@@ -0,0 +1,84 @@
+package statemachine_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestTransitionAllOrReport(t *testing.T) {
+	errNoStock := errors.New("out of stock")
+
+	tests := []struct {
+		name       string
+		setup      func(sm *statemachine.StateMachine)
+		wantErr    error
+		wantState  statemachine.State
+		wantReason []string
+	}{
+		{
+			name: "takes the first candidate whose guard passes",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddTransition("Cart", "Paid", func() bool { return false }, nil)
+				sm.AddTransition(statemachine.AnyState, "Paid", func() bool { return true }, nil)
+			},
+			wantState: "Paid",
+		},
+		{
+			name: "explains every failing candidate",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddTransition("Cart", "Paid", func() bool { return false }, nil)
+				sm.AddTransitionGuardErr(statemachine.AnyState, "Paid", func() (bool, error) { return false, errNoStock }, nil)
+			},
+			wantErr:   statemachine.ErrGuardFailed,
+			wantState: "Cart",
+			wantReason: []string{
+				"candidate 0 from Cart to Paid: the transition's guard was not satisfied",
+				"candidate 1 from * to Paid: the transition's guard was not satisfied: out of stock",
+			},
+		},
+		{
+			name: "tells the entry guard apart",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Cart", "Paid")
+				sm.SetEntryGuard("Paid", func() bool { return false })
+			},
+			wantErr:   statemachine.ErrGuardFailed,
+			wantState: "Cart",
+			wantReason: []string{
+				"candidate 0 from Cart to Paid: the target state's entry guard was not satisfied",
+			},
+		},
+		{
+			name:      "no candidates",
+			setup:     func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Cart", "Abandoned") },
+			wantErr:   statemachine.ErrInvalidTransition,
+			wantState: "Cart",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Cart")
+			tt.setup(sm)
+
+			err := sm.TransitionAllOrReport("Paid")
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("TransitionAllOrReport: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			for _, want := range tt.wantReason {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q doesn't mention %q", err, want)
+				}
+			}
+			if got := sm.CurrentState(); got != tt.wantState {
+				t.Fatalf("state = %v, want %v", got, tt.wantState)
+			}
+		})
+	}
+}
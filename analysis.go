@@ -0,0 +1,341 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoPath is returned by PathTo when the target can't be reached from the current state
+var ErrNoPath = errors.New("no path to state")
+
+// return every state the machine knows about: the initial state plus every state that appears
+// as the source or target of a transition, in a substate relationship, or was declared with
+// RegisterState. each state appears once, in the order it was first
+// registered after the initial state, and `AnyState` is never included
+func (sm *StateMachine) allStates() []State {
+	known := map[State]bool{}
+	for from, transitions := range sm.Transitions {
+		if !sm.sameState(from, AnyState) {
+			known[from] = true
+		}
+		for _, t := range transitions {
+			known[sm.key(t.To)] = true
+		}
+	}
+	for child, parent := range sm.parents {
+		known[sm.key(parent)] = true
+		known[child] = true
+	}
+	for s := range sm.registered {
+		known[s] = true
+	}
+	delete(known, sm.key(sm.InitialState))
+
+	return append([]State{sm.InitialState}, sm.inOrder(known)...)
+}
+
+// every transition leading out of a state: its own transitions, those inherited from its parent
+// states, and any wildcard transitions. a wildcard transition into the state itself is left out,
+// since "from anywhere" is not meant to give a state a loop back onto itself
+func (sm *StateMachine) outgoing(s State) []Transition {
+	transitions := sm.Transitions[sm.key(s)]
+	if sm.sameState(s, AnyState) {
+		return transitions
+	}
+
+	for _, parent := range sm.ancestors(s) {
+		if inherited := sm.Transitions[sm.key(parent)]; len(inherited) > 0 {
+			transitions = append(transitions[:len(transitions):len(transitions)], inherited...)
+		}
+	}
+
+	for _, t := range sm.Transitions[AnyState] {
+		if !sm.sameState(t.To, s) {
+			transitions = append(transitions[:len(transitions):len(transitions)], t)
+		}
+	}
+	return transitions
+}
+
+// the states directly reachable from a state through a single transition, ignoring guards
+func (sm *StateMachine) successors(s State) []State {
+	var next []State
+	for _, t := range sm.outgoing(s) {
+		next = append(next, t.To)
+	}
+	return next
+}
+
+// walk the transition graph breadth-first from a starting state, ignoring guards, and return
+// the keys (see `key`) of every state that can be reached, including the starting state itself
+func (sm *StateMachine) reachableFrom(start State) map[State]bool {
+	visited := map[State]bool{sm.key(start): true}
+	queue := []State{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range sm.successors(current) {
+			if !visited[sm.key(next)] {
+				visited[sm.key(next)] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return visited
+}
+
+// UnreachableStates is a design-time check that returns every state referenced by a transition
+// that can never be reached from the initial state. guards are ignored, since they can only be
+// evaluated at runtime, so a state reported here is unreachable no matter what the guards say
+func (sm *StateMachine) UnreachableStates() []State {
+	reachable := sm.reachableFrom(sm.InitialState)
+
+	var unreachable []State
+	for _, s := range sm.allStates() {
+		if !reachable[sm.key(s)] {
+			unreachable = append(unreachable, s)
+		}
+	}
+	return unreachable
+}
+
+// TerminalStates returns every state that is the target of some transition but has no outgoing
+// transitions of its own, either because it was never registered as a source or because its
+// list of transitions is empty. these are the dead ends of the machine - useful for confirming
+// that "completed" style states are the only places a workflow can stop
+func (sm *StateMachine) TerminalStates() []State {
+	targets := map[State]bool{}
+	for _, transitions := range sm.Transitions {
+		for _, t := range transitions {
+			targets[sm.key(t.To)] = true
+		}
+	}
+
+	var terminal []State
+	for _, s := range sm.allStates() {
+		if targets[sm.key(s)] && len(sm.outgoing(s)) == 0 {
+			terminal = append(terminal, s)
+		}
+	}
+	return terminal
+}
+
+// RedundantTransitions returns the edges that add nothing to the machine: an edge is considered
+// redundant only when it has no guard, action, or event, and its target can still be reached from its
+// source through some other path once the edge is removed. this is deliberately conservative -
+// anything carrying a guard, action, or event is assumed to be there for a reason and is never reported
+func (sm *StateMachine) RedundantTransitions() [][2]State {
+	var redundant [][2]State
+	for _, from := range sm.sources() {
+		if sm.sameState(from, AnyState) {
+			continue
+		}
+		for i, t := range sm.Transitions[sm.key(from)] {
+			if !t.isPlain() || sm.sameState(t.To, from) {
+				continue
+			}
+			if sm.reachableWithout(from, t.To, from, i) {
+				redundant = append(redundant, [2]State{from, t.To})
+			}
+		}
+	}
+	return redundant
+}
+
+// report whether `to` can be reached from `from` when the transition at the given index of the
+// `skipFrom` state's transition list is ignored. a state's own transitions come first in `outgoing`,
+// so the index lines up with its position in the transition table
+func (sm *StateMachine) reachableWithout(from, to, skipFrom State, skipIndex int) bool {
+	visited := map[State]bool{sm.key(from): true}
+	queue := []State{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for i, t := range sm.outgoing(current) {
+			if sm.sameState(current, skipFrom) && i == skipIndex {
+				continue
+			}
+			if sm.sameState(t.To, to) {
+				return true
+			}
+			if !visited[sm.key(t.To)] {
+				visited[sm.key(t.To)] = true
+				queue = append(queue, t.To)
+			}
+		}
+	}
+	return false
+}
+
+// map the key (see `key`) of every state to the distinct states that have a transition into it
+func (sm *StateMachine) predecessors() map[State][]State {
+	preds := map[State][]State{}
+	seen := map[[2]State]bool{}
+	for _, from := range sm.allStates() {
+		for _, t := range sm.outgoing(from) {
+			edge := [2]State{sm.key(from), sm.key(t.To)}
+			if seen[edge] {
+				continue
+			}
+			seen[edge] = true
+			preds[edge[1]] = append(preds[edge[1]], from)
+		}
+	}
+	return preds
+}
+
+// NonTreeStates returns every state that can be entered from more than one other state, i.e. the
+// points where separate branches of the machine merge back together
+func (sm *StateMachine) NonTreeStates() []State {
+	preds := sm.predecessors()
+
+	var merges []State
+	for _, s := range sm.allStates() {
+		if len(preds[sm.key(s)]) > 1 {
+			merges = append(merges, s)
+		}
+	}
+	return merges
+}
+
+// report whether the machine is tree-shaped, meaning every state has at most one predecessor.
+// this only checks for merges - a branch that loops back on itself still has one predecessor
+// per state and is not reported
+func (sm *StateMachine) IsTree() bool {
+	return len(sm.NonTreeStates()) == 0
+}
+
+// Distances returns the minimum number of transitions needed to reach each state from the current
+// state, with the current state itself at distance 0. guards are ignored, and states that can't be
+// reached at all are left out of the map. handy for progress indicators ("3 steps from done")
+func (sm *StateMachine) Distances() map[State]int {
+	start := sm.current()
+	distances := map[State]int{sm.key(start): 0}
+	queue := []State{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range sm.successors(current) {
+			if _, seen := distances[sm.key(next)]; !seen {
+				distances[sm.key(next)] = distances[sm.key(current)] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return distances
+}
+
+// PathTo returns the shortest sequence of states leading from the current state to `target`, starting
+// with the current state and ending with the target. guards are ignored since they depend on runtime
+// conditions, so the path shows what's possible in principle rather than what's allowed right now
+func (sm *StateMachine) PathTo(target State) ([]State, error) {
+	start := sm.current()
+	previous := map[State]State{}
+	visited := map[State]bool{sm.key(start): true}
+	queue := []State{start}
+	for len(queue) > 0 && !visited[sm.key(target)] {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range sm.successors(current) {
+			if !visited[sm.key(next)] {
+				visited[sm.key(next)] = true
+				previous[sm.key(next)] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	if !visited[sm.key(target)] {
+		return nil, fmt.Errorf("%w: from %s to %s", ErrNoPath, stateName(start), stateName(target))
+	}
+
+	// walk back from the target to the start, then flip the result around
+	path := []State{target}
+	for s := target; !sm.sameState(s, start); {
+		s = previous[sm.key(s)]
+		path = append(path, s)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
+
+// report whether the transition graph contains a cycle, i.e. whether the machine could ever come back
+// to a state it has already left (a state with a transition to itself counts). guards are ignored
+func (sm *StateMachine) HasCycle() bool {
+	return len(sm.Cycles()) > 0
+}
+
+// Cycles returns the cycles found by a depth-first search of the transition graph, ignoring guards.
+// each cycle lists its states in order, with the first state following on from the last, so a
+// self-loop is a single state. one cycle is reported for every edge that leads back into the current
+// search path, which finds a cycle wherever there is one but not every possible cycle through the
+// same states. an empty result means the graph is a DAG
+func (sm *StateMachine) Cycles() [][]State {
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	status := map[State]int{}
+	var path []State
+	var cycles [][]State
+
+	var visit func(s State)
+	visit = func(s State) {
+		status[sm.key(s)] = onPath
+		path = append(path, s)
+
+		seen := map[State]bool{}
+		for _, next := range sm.successors(s) {
+			if seen[sm.key(next)] {
+				continue
+			}
+			seen[sm.key(next)] = true
+
+			switch status[sm.key(next)] {
+			case unvisited:
+				visit(next)
+			case onPath:
+				// walk back along the path to where the cycle starts
+				start := len(path) - 1
+				for !sm.sameState(path[start], next) {
+					start--
+				}
+				cycles = append(cycles, append([]State(nil), path[start:]...))
+			}
+		}
+
+		path = path[:len(path)-1]
+		status[sm.key(s)] = done
+	}
+
+	for _, s := range sm.allStates() {
+		if status[sm.key(s)] == unvisited {
+			visit(s)
+		}
+	}
+	return cycles
+}
+
+// OutDegree returns how many transitions lead out of a state, counting those inherited from its
+// parent states and wildcard transitions along with its own
+func (sm *StateMachine) OutDegree(s State) int {
+	return len(sm.outgoing(s))
+}
+
+// InDegree returns how many transitions lead into a state from the states the machine knows about.
+// like OutDegree it works on the effective graph, so a wildcard transition into the state counts once
+// for every other state it can be taken from. the initial state may well have an in-degree of zero
+func (sm *StateMachine) InDegree(s State) int {
+	degree := 0
+	for _, from := range sm.allStates() {
+		for _, t := range sm.outgoing(from) {
+			if sm.sameState(t.To, s) {
+				degree++
+			}
+		}
+	}
+	return degree
+}
@@ -0,0 +1,231 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+// an order workflow: Created -> Paid -> Shipped -> Delivered, with Cancelled reachable from the
+// first two states and Refunded left unreachable
+func newOrderMachine() *statemachine.StateMachine {
+	sm := statemachine.NewStateMachine("Created")
+	sm.AddSimpleTransition("Created", "Paid")
+	sm.AddSimpleTransition("Created", "Cancelled")
+	sm.AddSimpleTransition("Paid", "Shipped")
+	sm.AddSimpleTransition("Paid", "Cancelled")
+	sm.AddSimpleTransition("Shipped", "Delivered")
+	sm.AddSimpleTransition("Refunded", "Created")
+	return sm
+}
+
+func TestUnreachableStates(t *testing.T) {
+	sm := newOrderMachine()
+	if got, want := sm.UnreachableStates(), []statemachine.State{"Refunded"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("UnreachableStates = %v, want %v", got, want)
+	}
+}
+
+func TestTerminalStates(t *testing.T) {
+	sm := newOrderMachine()
+	got := sm.TerminalStates()
+	if want := []statemachine.State{"Cancelled", "Delivered"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TerminalStates = %v, want %v", got, want)
+	}
+}
+
+func TestRedundantTransitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(sm *statemachine.StateMachine)
+		want  [][2]statemachine.State
+	}{
+		{
+			name: "shortcut covered by another path",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("A", "B")
+				sm.AddSimpleTransition("A", "C")
+				sm.AddSimpleTransition("B", "C")
+			},
+			want: [][2]statemachine.State{{"A", "C"}},
+		},
+		{
+			name: "guarded shortcut is kept",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("A", "B")
+				sm.AddTransition("A", "C", func() bool { return true }, nil)
+				sm.AddSimpleTransition("B", "C")
+			},
+		},
+		{
+			name: "no alternative path",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("A", "B")
+				sm.AddSimpleTransition("A", "C")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("A")
+			tt.setup(sm)
+			if got := sm.RedundantTransitions(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("RedundantTransitions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTree(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(sm *statemachine.StateMachine)
+		want      bool
+		wantMerge []statemachine.State
+	}{
+		{
+			name: "branches only",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("A", "B")
+				sm.AddSimpleTransition("A", "C")
+				sm.AddSimpleTransition("B", "D")
+			},
+			want: true,
+		},
+		{
+			name: "branches merge",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("A", "B")
+				sm.AddSimpleTransition("A", "C")
+				sm.AddSimpleTransition("B", "D")
+				sm.AddSimpleTransition("C", "D")
+			},
+			wantMerge: []statemachine.State{"D"},
+		},
+		{
+			name: "loop back to the root",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("A", "B")
+				sm.AddSimpleTransition("B", "A")
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("A")
+			tt.setup(sm)
+			if got := sm.IsTree(); got != tt.want {
+				t.Fatalf("IsTree = %v, want %v", got, tt.want)
+			}
+			if got := sm.NonTreeStates(); !reflect.DeepEqual(got, tt.wantMerge) {
+				t.Fatalf("NonTreeStates = %v, want %v", got, tt.wantMerge)
+			}
+		})
+	}
+}
+
+func TestDistances(t *testing.T) {
+	sm := newOrderMachine()
+	sm.Transition("Paid")
+
+	want := map[statemachine.State]int{"Paid": 0, "Shipped": 1, "Cancelled": 1, "Delivered": 2}
+	if got := sm.Distances(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Distances = %v, want %v", got, want)
+	}
+}
+
+func TestPathTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  statemachine.State
+		want    []statemachine.State
+		wantErr error
+	}{
+		{name: "shortest path", target: "Delivered", want: []statemachine.State{"Created", "Paid", "Shipped", "Delivered"}},
+		{name: "already there", target: "Created", want: []statemachine.State{"Created"}},
+		{name: "unreachable", target: "Refunded", wantErr: statemachine.ErrNoPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newOrderMachine().PathTo(tt.target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PathTo error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("PathTo = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(sm *statemachine.StateMachine)
+		want  [][]statemachine.State
+	}{
+		{
+			name:  "acyclic",
+			setup: func(sm *statemachine.StateMachine) { sm.AddTransitions("A", "B", "C"); sm.AddTransitions("B", "C") },
+		},
+		{
+			name: "loop through several states",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddTransitions("A", "B")
+				sm.AddTransitions("B", "C")
+				sm.AddTransitions("C", "A")
+			},
+			want: [][]statemachine.State{{"A", "B", "C"}},
+		},
+		{
+			name:  "self-loop",
+			setup: func(sm *statemachine.StateMachine) { sm.AddTransitions("A", "B"); sm.AddSelfTransition("B", nil, nil) },
+			want:  [][]statemachine.State{{"B"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("A")
+			tt.setup(sm)
+			got := sm.Cycles()
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Fatalf("Cycles = %v, want %v", got, tt.want)
+			}
+			if sm.HasCycle() != (len(tt.want) > 0) {
+				t.Fatalf("HasCycle = %v, want %v", sm.HasCycle(), len(tt.want) > 0)
+			}
+		})
+	}
+}
+
+func TestDegrees(t *testing.T) {
+	sm := newOrderMachine()
+	sm.AddTransition(statemachine.AnyState, "Archived", nil, nil)
+
+	tests := []struct {
+		state   statemachine.State
+		wantIn  int
+		wantOut int
+	}{
+		{state: "Created", wantIn: 1, wantOut: 3},
+		{state: "Shipped", wantIn: 1, wantOut: 2},
+		{state: "Cancelled", wantIn: 2, wantOut: 1},
+		{state: "Archived", wantIn: 6, wantOut: 0},
+	}
+
+	for _, tt := range tests {
+		if got := sm.InDegree(tt.state); got != tt.wantIn {
+			t.Errorf("InDegree(%v) = %d, want %d", tt.state, got, tt.wantIn)
+		}
+		if got := sm.OutDegree(tt.state); got != tt.wantOut {
+			t.Errorf("OutDegree(%v) = %d, want %d", tt.state, got, tt.wantOut)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStepBudgetExceeded is returned once the machine has made as many transitions as SetMaxTransitions allows
+var ErrStepBudgetExceeded = errors.New("transition budget exceeded")
+
+// cap the number of successful transitions the machine may make, as a safety net against runaway
+// workflows and event storms. once `n` transitions have been made, every further transition is
+// rejected with ErrStepBudgetExceeded until Reset or ResetWithEntry starts the count over. zero, the
+// default, means unlimited
+func (sm *StateMachine) SetMaxTransitions(n int) {
+	sm.maxTransitions = n
+}
+
+// reject a transition when the machine has used up its transition budget
+func (sm *StateMachine) checkBudget(from, to State) error {
+	if sm.maxTransitions <= 0 {
+		return nil
+	}
+
+	sm.mu.RLock()
+	steps := sm.steps
+	sm.mu.RUnlock()
+
+	if steps >= sm.maxTransitions {
+		return fmt.Errorf("%w: %d transitions made, from %s to %s", ErrStepBudgetExceeded, steps, stateName(from), stateName(to))
+	}
+	return nil
+}
+
+// count a successful transition against the budget
+func (sm *StateMachine) countStep() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.steps++
+}
+
+// start the transition budget over
+func (sm *StateMachine) resetSteps() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.steps = 0
+}
@@ -0,0 +1,46 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestSetMaxTransitions(t *testing.T) {
+	sm := statemachine.NewStateMachine("Ping")
+	sm.AddTransitions("Ping", "Pong")
+	sm.AddTransitions("Pong", "Ping")
+	sm.SetMaxTransitions(2)
+
+	for _, to := range []statemachine.State{"Pong", "Ping"} {
+		if err := sm.Transition(to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sm.Transition("Pong"); !errors.Is(err, statemachine.ErrStepBudgetExceeded) {
+		t.Fatalf("third transition = %v, want ErrStepBudgetExceeded", err)
+	}
+	if got := sm.CurrentState(); got != "Ping" {
+		t.Fatalf("state = %v, want Ping", got)
+	}
+
+	sm.Reset()
+	if err := sm.Transition("Pong"); err != nil {
+		t.Fatalf("transition after Reset = %v", err)
+	}
+}
+
+func TestSetMaxTransitionsIgnoresFailures(t *testing.T) {
+	sm := statemachine.NewStateMachine("Ping")
+	sm.AddTransition("Ping", "Pong", func() bool { return false }, nil)
+	sm.AddTransitions("Ping", "Pang")
+	sm.SetMaxTransitions(1)
+
+	for i := 0; i < 3; i++ {
+		sm.Transition("Pong")
+	}
+	if err := sm.Transition("Pang"); err != nil {
+		t.Fatalf("failed transitions used up the budget: %v", err)
+	}
+}
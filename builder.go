@@ -0,0 +1,108 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Builder offers a fluent way to declare a state machine so that definitions read top to bottom:
+//
+//	sm, err := statemachine.NewBuilder(Off).
+//		From(Off).To(On).When(hasPower).Do(flipSwitch).
+//		From(On).To(Off).
+//		OnEntry(On, turnOnLight).
+//		Build()
+//
+// Mistakes made while building are collected and reported together by Build.
+type Builder struct {
+	initial      State
+	from         State
+	transitions  []Transition
+	entryActions map[State]Action
+	exitActions  map[State]Action
+	errs         []error
+}
+
+func NewBuilder(initial State) *Builder {
+	return &Builder{
+		initial:      initial,
+		entryActions: make(map[State]Action),
+		exitActions:  make(map[State]Action),
+	}
+}
+
+// set the source state used by the following calls to `To`
+func (b *Builder) From(s State) *Builder {
+	b.from = s
+	return b
+}
+
+// declare a transition from the current source state to the given target
+func (b *Builder) To(s State) *Builder {
+	if b.from == nil {
+		b.errs = append(b.errs, fmt.Errorf("%w: transition to %s has no source state", ErrInvalidDefinition, stateName(s)))
+	}
+	b.transitions = append(b.transitions, Transition{From: b.from, To: s})
+	return b
+}
+
+// attach a guard to the most recently declared transition
+func (b *Builder) When(guard Guard) *Builder {
+	if t := b.last("guard"); t != nil {
+		t.Guard = guard
+	}
+	return b
+}
+
+// attach an action to the most recently declared transition
+func (b *Builder) Do(action Action) *Builder {
+	if t := b.last("action"); t != nil {
+		t.Action = action
+	}
+	return b
+}
+
+func (b *Builder) OnEntry(s State, action Action) *Builder {
+	b.entryActions[s] = action
+	return b
+}
+
+func (b *Builder) OnExit(s State, action Action) *Builder {
+	b.exitActions[s] = action
+	return b
+}
+
+// create the state machine, or return every problem found while it was being declared
+func (b *Builder) Build() (*StateMachine, error) {
+	if len(b.errs) > 0 {
+		return nil, errors.Join(b.errs...)
+	}
+
+	sm := NewStateMachine(b.initial)
+	for _, t := range b.transitions {
+		sm.AddTransition(t.From, t.To, t.Guard, t.Action)
+	}
+	for s, action := range b.entryActions {
+		sm.SetEntryAction(s, action)
+	}
+	for s, action := range b.exitActions {
+		sm.SetExitAction(s, action)
+	}
+	return sm, nil
+}
+
+// the transition that a guard or action should be attached to. guards and actions only make
+// sense on a transition with a known source, so anything else is recorded as a build error
+func (b *Builder) last(kind string) *Transition {
+	if len(b.transitions) == 0 {
+		b.errs = append(b.errs, fmt.Errorf("%w: %s declared before any transition", ErrInvalidDefinition, kind))
+		return nil
+	}
+
+	t := &b.transitions[len(b.transitions)-1]
+	if t.From == nil {
+		b.errs = append(b.errs, fmt.Errorf("%w: %s attached to transition to %s with no source state", ErrInvalidDefinition, kind, stateName(t.To)))
+		return nil
+	}
+	return t
+}
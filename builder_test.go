@@ -0,0 +1,67 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestBuilder(t *testing.T) {
+	var log []string
+	sm, err := statemachine.NewBuilder("Off").
+		From("Off").To("On").When(func() bool { return true }).Do(logged(&log, "flip")).
+		From("On").To("Off").
+		OnEntry("On", logged(&log, "light on")).
+		OnExit("On", logged(&log, "light off")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, to := range []statemachine.State{"On", "Off"} {
+		if err := sm.Transition(to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"flip", "light on", "light off"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("ran %v, want %v", log, want)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() *statemachine.Builder
+	}{
+		{
+			name:  "transition without a source",
+			build: func() *statemachine.Builder { return statemachine.NewBuilder("Off").To("On") },
+		},
+		{
+			name: "guard before any transition",
+			build: func() *statemachine.Builder {
+				return statemachine.NewBuilder("Off").When(func() bool { return true })
+			},
+		},
+		{
+			name: "action before any transition",
+			build: func() *statemachine.Builder {
+				return statemachine.NewBuilder("Off").Do(func() error { return nil })
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, err := tt.build().Build()
+			if !errors.Is(err, statemachine.ErrInvalidDefinition) {
+				t.Fatalf("Build error = %v, want ErrInvalidDefinition", err)
+			}
+			if sm != nil {
+				t.Fatal("Build returned a machine alongside an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package statemachine
+
+import "sort"
+
+// CandidateFilter narrows down (or reorders) the transitions considered when leaving a state. It
+// receives a copy of the transitions registered for the source state and returns the ones that
+// should be eligible, in the order they should be matched. Returning an empty slice blocks every
+// transition out of the state.
+type CandidateFilter func(from State, candidates []Transition) []Transition
+
+// install a filter that is applied whenever the machine looks for a transition out of a state, e.g.
+// to switch certain edges off behind a runtime feature flag. passing nil removes the filter
+func (sm *StateMachine) SetCandidateFilter(filter CandidateFilter) {
+	sm.candidateFilter = filter
+}
+
+// the transitions eligible to leave the given state, after the candidate filter has been applied.
+// the state's own transitions come first, then those inherited from its parent states (innermost
+// first), then any wildcard transitions, so the most specific transition wins when several match.
+// transitions given a priority are then moved ahead of the rest, highest priority first
+func (sm *StateMachine) candidates(from State) []Transition {
+	transitions := sm.Transitions[sm.key(from)]
+	for _, parent := range sm.ancestors(from) {
+		if inherited := sm.Transitions[sm.key(parent)]; len(inherited) > 0 {
+			transitions = append(transitions[:len(transitions):len(transitions)], inherited...)
+		}
+	}
+	if wildcards := sm.Transitions[AnyState]; len(wildcards) > 0 && !sm.sameState(from, AnyState) {
+		transitions = append(transitions[:len(transitions):len(transitions)], wildcards...)
+	}
+	transitions = byPriority(transitions)
+	if sm.candidateFilter == nil {
+		return transitions
+	}
+
+	// hand the filter its own copy so it can't reorder or overwrite the registered transitions
+	candidates := make([]Transition, len(transitions))
+	copy(candidates, transitions)
+	return sm.candidateFilter(from, candidates)
+}
+
+// order transitions by descending priority, keeping registration order between equal priorities. the
+// registered slice is never sorted in place - when any reordering is needed, a sorted copy is returned
+func byPriority(transitions []Transition) []Transition {
+	prioritized := false
+	for _, t := range transitions {
+		if t.Priority != 0 {
+			prioritized = true
+			break
+		}
+	}
+	if !prioritized {
+		return transitions
+	}
+
+	sorted := make([]Transition, len(transitions))
+	copy(sorted, transitions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	return sorted
+}
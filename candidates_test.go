@@ -0,0 +1,46 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestCandidateFilter(t *testing.T) {
+	betaEnabled := false
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddSimpleTransition("Draft", "Review")
+	sm.AddSimpleTransition("Draft", "Published")
+	sm.SetCandidateFilter(func(from statemachine.State, candidates []statemachine.Transition) []statemachine.Transition {
+		var kept []statemachine.Transition
+		for _, t := range candidates {
+			if t.To != "Published" || betaEnabled {
+				kept = append(kept, t)
+			}
+		}
+		return kept
+	})
+
+	if err := sm.Transition("Published"); !errors.Is(err, statemachine.ErrInvalidTransition) {
+		t.Fatalf("filtered transition error = %v, want ErrInvalidTransition", err)
+	}
+	betaEnabled = true
+	if err := sm.Transition("Published"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCandidateFilterCantChangeRegistration(t *testing.T) {
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddSimpleTransition("Draft", "Review")
+	sm.SetCandidateFilter(func(from statemachine.State, candidates []statemachine.Transition) []statemachine.Transition {
+		candidates[0].To = "Published"
+		return nil
+	})
+	sm.Transition("Review")
+
+	if got := sm.Transitions["Draft"][0].To; got != "Review" {
+		t.Fatalf("registered transition now leads to %v, want Review", got)
+	}
+}
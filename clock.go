@@ -0,0 +1,27 @@
+package statemachine
+
+import "time"
+
+// Clock is the machine's source of time. Everything time-dependent goes through it rather than
+// calling the time package directly, so that it can be swapped out for a controllable one with
+// SetClock - see statemachinetest.FakeClock for one meant for tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// the default clock, backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// set the clock used for timeouts, backoffs, debouncing and timestamps. passing nil restores the
+// default, which uses the time package. timers that are already running keep the clock they started
+// with, so set the clock before the machine starts transitioning
+func (sm *StateMachine) SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+	sm.clock = c
+}
@@ -0,0 +1,76 @@
+package statemachine
+
+// create an independent copy of the machine's configuration. the transition table and action
+// registries are copied so that changes to the clone never affect the original (the guard and
+// action functions themselves are shared). the clone starts out in its initial state
+func (sm *StateMachine) Clone() *StateMachine {
+	clone := NewStateMachine(sm.InitialState, WithStateComparator(sm.equal))
+	clone.remember(sm.order...)
+
+	for from, transitions := range sm.Transitions {
+		clone.Transitions[from] = copyTransitions(transitions)
+	}
+	for s, action := range sm.entryActions {
+		clone.entryActions[s] = action
+	}
+	for s, action := range sm.postEntryActions {
+		clone.postEntryActions[s] = action
+	}
+	for pair, action := range sm.entryActionsFrom {
+		clone.entryActionsFrom[pair] = action
+	}
+	for s, guard := range sm.entryGuards {
+		clone.entryGuards[s] = guard
+	}
+	for s, action := range sm.reentryActions {
+		clone.reentryActions[s] = action
+	}
+	for s, action := range sm.exitActions {
+		clone.exitActions[s] = action
+	}
+	for s, action := range sm.exitCompensations {
+		clone.exitCompensations[s] = action
+	}
+	for from, predicates := range sm.predicates {
+		clone.predicates[from] = append([]predicateTransition(nil), predicates...)
+	}
+	for s, action := range sm.twoPhaseActions {
+		clone.twoPhaseActions[s] = action
+	}
+	clone.candidateFilter = sm.candidateFilter
+	clone.beforeCommit = sm.beforeCommit
+	clone.beforeHooks = append(clone.beforeHooks, sm.beforeHooks...)
+	clone.logger = sm.logger
+	clone.events = sm.events
+	clone.recorder = sm.recorder
+	clone.defaultHandler = sm.defaultHandler
+	clone.allowUnknown = sm.allowUnknown
+	clone.transitionTimeout = sm.transitionTimeout
+	for s := range sm.registered {
+		clone.registered[s] = true
+	}
+	clone.strict = sm.strict
+	for s := range sm.nonTerminal {
+		clone.nonTerminal[s] = true
+	}
+	clone.afterHooks = append(clone.afterHooks, sm.afterHooks...)
+	clone.maxChainDepth = sm.maxChainDepth
+	clone.trackSources = sm.trackSources
+	clone.guardAttempts = sm.guardAttempts
+	clone.cacheGuards = sm.cacheGuards
+	clone.maxTransitions = sm.maxTransitions
+	clone.guardBackoff = sm.guardBackoff
+	for s, t := range sm.timeouts {
+		clone.timeouts[s] = t
+	}
+	for child, parent := range sm.parents {
+		clone.parents[child] = parent
+	}
+	for s, d := range sm.debounces {
+		clone.debounces[s] = d
+	}
+	clone.clock = sm.clock
+	clone.rollbackMode = sm.rollbackMode
+
+	return clone
+}
@@ -0,0 +1,40 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestClone(t *testing.T) {
+	entries := 0
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddSimpleTransition("Off", "On")
+	sm.SetEntryAction("On", func() error {
+		entries++
+		return nil
+	})
+	sm.Transition("On")
+
+	clone := sm.Clone()
+	if got := clone.CurrentState(); got != "Off" {
+		t.Fatalf("clone starts in %v, want its initial state Off", got)
+	}
+	if err := clone.Transition("On"); err != nil {
+		t.Fatal(err)
+	}
+	if entries != 2 {
+		t.Fatalf("entry action ran %d times, want the clone to share it", entries)
+	}
+
+	clone.AddSimpleTransition("On", "Broken")
+	if err := sm.Transition("Broken"); !errors.Is(err, statemachine.ErrInvalidTransition) {
+		t.Fatalf("the clone's new transition leaked into the original: %v", err)
+	}
+	sm.AddSimpleTransition("Off", "Standby")
+	clone.Reset()
+	if err := clone.Transition("Standby"); !errors.Is(err, statemachine.ErrInvalidTransition) {
+		t.Fatalf("the original's new transition leaked into the clone: %v", err)
+	}
+}
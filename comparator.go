@@ -0,0 +1,104 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrUncomparableState is the panic value (wrapped) when a state that can't be compared with == is
+// given to a machine without a state comparator
+var ErrUncomparableState = errors.New("state type is not comparable")
+
+// set the function used to decide whether two states are the same, e.g. comparing pointer states by
+// an ID field rather than by address, or states that can't be compared with == at all, such as slices
+// or structs holding one. it's used to match the requested target against each registered transition
+// (in Transition, CanTransition and friends), to spot self-transitions, to deduplicate registrations,
+// to look states up in the machine's tables (actions, guards, timeouts, visit counts and so on), in
+// the graph analysis (PathTo, InDegree and friends), and wherever the machine checks whether it's
+// still in (or inside) a state. AnyState only ever matches itself, so the comparator is never asked
+// about it. passing nil restores the default, ==
+//
+// the comparator should be set before any state it affects is registered: a machine whose initial
+// state can't be compared with == needs WithStateComparator instead, since NewStateMachine registers
+// the initial state. states that can't be map keys are stored under a stand-in key, so they can't be
+// told apart in the maps the machine hands out (the Transitions table, TransitionMap, TransitionsCopy,
+// AllVisitCounts and Distances) - use the methods that return states, such as States, instead
+func (sm *StateMachine) SetStateComparator(equal func(a, b State) bool) {
+	sm.equal = equal
+}
+
+// WithStateComparator sets the machine's state comparator (see SetStateComparator) before the initial
+// state is registered, which is required when the initial state can't be compared with ==
+func WithStateComparator(equal func(a, b State) bool) Option {
+	return func(sm *StateMachine) {
+		sm.equal = equal
+	}
+}
+
+// report whether two states are the same according to the machine's comparator
+func (sm *StateMachine) sameState(a, b State) bool {
+	if sm.equal == nil {
+		return a == b
+	}
+	// the wildcard only ever matches itself. comparing it with == is safe whatever the other
+	// state is, since values of different types are never equal
+	if a == AnyState || b == AnyState {
+		return a == b
+	}
+	return sm.equal(a, b)
+}
+
+// stands in for a state that can't be a map key, in the machine's tables: the state's position in
+// registration order
+type stateKey int
+
+// the key a state is stored under in the machine's tables. without a comparator that's the state
+// itself. with one, it's the key of the first registered state the comparator considers the same, so
+// the tables can be looked up with any value describing a registered state. a state that isn't
+// registered is its own key if it can be a map key, and is registered otherwise so it gets a stand-in
+func (sm *StateMachine) key(s State) State {
+	if sm.equal == nil {
+		return s
+	}
+	if k, ok := sm.knownKey(s); ok {
+		return k
+	}
+	if hashable(s) {
+		return s
+	}
+	sm.remember(s)
+	return sm.keys[len(sm.keys)-1]
+}
+
+// the key of the first registered state the comparator considers the same as s
+func (sm *StateMachine) knownKey(s State) (State, bool) {
+	for i, known := range sm.order {
+		if sm.sameState(known, s) {
+			return sm.keys[i], true
+		}
+	}
+	return nil, false
+}
+
+// the state a key from one of the machine's tables stands for
+func (sm *StateMachine) stateOf(k State) State {
+	if i, ok := k.(stateKey); ok {
+		return sm.order[i]
+	}
+	return k
+}
+
+// report whether a state can be compared with == and used as a map key
+func hashable(s State) bool {
+	t := reflect.TypeOf(s)
+	return t == nil || t.Comparable()
+}
+
+// panic with ErrUncomparableState when a state can't be compared with == and there's no comparator
+// to compare it with, rather than letting the runtime panic somewhere less obvious later on
+func (sm *StateMachine) mustBeComparable(s State) {
+	if sm.equal == nil && !hashable(s) {
+		panic(fmt.Errorf("%w: %s of type %T needs a state comparator, see WithStateComparator", ErrUncomparableState, stateName(s), s))
+	}
+}
@@ -0,0 +1,163 @@
+package statemachine_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+// a state whose == compares addresses, so two values describing the same state aren't equal
+type docState struct{ name string }
+
+// a state that can't be compared with == at all
+type sliceState struct{ parts []string }
+
+func sameDoc(a, b statemachine.State) bool {
+	x, okX := a.(*docState)
+	y, okY := b.(*docState)
+	return okX && okY && x.name == y.name
+}
+
+func TestStateComparator(t *testing.T) {
+	draft, review := &docState{"draft"}, &docState{"review"}
+
+	tests := []struct {
+		name       string
+		comparator func(a, b statemachine.State) bool
+		wantErr    error
+	}{
+		{name: "default ==", comparator: nil, wantErr: statemachine.ErrInvalidTransition},
+		{name: "custom comparator", comparator: sameDoc, wantErr: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine(draft)
+			sm.AddSimpleTransition(draft, review)
+			sm.SetStateComparator(tt.comparator)
+
+			// a fresh value describing the same state as the registered one
+			target := &docState{"review"}
+			if got, want := sm.CanTransition(target), tt.wantErr == nil; got != want {
+				t.Fatalf("CanTransition = %v, want %v", got, want)
+			}
+			err := sm.Transition(target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && sm.CurrentState() != statemachine.State(review) {
+				t.Fatalf("state = %v, want the registered review state", sm.CurrentState())
+			}
+		})
+	}
+}
+
+func TestStateComparatorSpotsSelfTransitions(t *testing.T) {
+	draft := &docState{"draft"}
+	sm := statemachine.NewStateMachine(draft)
+	sm.AddSimpleTransition(draft, &docState{"draft"})
+	sm.SetStateComparator(sameDoc)
+
+	if err := sm.Transition(&docState{"draft"}); !errors.Is(err, statemachine.ErrSelfTransition) {
+		t.Fatalf("Transition = %v, want ErrSelfTransition", err)
+	}
+}
+
+// compares states made of slices by their contents
+func sameParts(a, b statemachine.State) bool {
+	x, okX := a.(sliceState)
+	y, okY := b.(sliceState)
+	return okX && okY && slices.Equal(x.parts, y.parts)
+}
+
+func parts(p ...string) sliceState { return sliceState{parts: p} }
+
+func TestUncomparableStatesWithComparator(t *testing.T) {
+	sm := statemachine.NewStateMachine(parts("draft"), statemachine.WithStateComparator(sameParts))
+	sm.AddSimpleTransition(parts("draft"), parts("review")).AddSimpleTransition(parts("review"), parts("published"))
+
+	entered := 0
+	sm.SetEntryAction(parts("review"), func() error {
+		entered++
+		return nil
+	})
+
+	if !sm.CanTransition(parts("review")) {
+		t.Fatal("CanTransition(review) = false, want true")
+	}
+	if err := sm.Transition(parts("review")); err != nil {
+		t.Fatalf("Transition(review) = %v", err)
+	}
+	if entered != 1 {
+		t.Fatalf("review's entry action ran %d times, want 1", entered)
+	}
+	if got := sm.VisitCount(parts("review")); got != 1 {
+		t.Fatalf("VisitCount(review) = %d, want 1", got)
+	}
+	if got := sm.States(); len(got) != 3 {
+		t.Fatalf("States = %v, want draft, review and published", got)
+	}
+	if path, err := sm.PathTo(parts("published")); err != nil || len(path) != 2 {
+		t.Fatalf("PathTo(published) = %v, %v, want [review published]", path, err)
+	}
+	if clone := sm.Clone(); !clone.Equal(sm) || !clone.CanTransition(parts("review")) {
+		t.Fatal("the clone doesn't match the original")
+	}
+}
+
+// with a comparator, the analysis finds a state whichever value describes it
+func TestStateComparatorInAnalysis(t *testing.T) {
+	build := func() *statemachine.StateMachine {
+		sm := statemachine.NewStateMachine(&docState{"draft"})
+		sm.SetStateComparator(sameDoc)
+		sm.AddSimpleTransition(&docState{"draft"}, &docState{"review"})
+		sm.AddSimpleTransition(&docState{"review"}, &docState{"published"})
+		return sm
+	}
+	sm := build()
+	review := &docState{"review"}
+
+	if !sm.CanTransition(review) {
+		t.Fatal("CanTransition(review) = false, want true")
+	}
+	if path, err := sm.PathTo(&docState{"published"}); err != nil || len(path) != 3 {
+		t.Fatalf("PathTo(published) = %v, %v, want three states", path, err)
+	}
+	if got := sm.InDegree(review); got != 1 {
+		t.Fatalf("InDegree(review) = %d, want 1", got)
+	}
+	if got := sm.OutDegree(review); got != 1 {
+		t.Fatalf("OutDegree(review) = %d, want 1", got)
+	}
+	if got := sm.Distances()[sm.States()[2]]; got != 2 {
+		t.Fatalf("distance to published = %d, want 2", got)
+	}
+	if diff := sm.Diff(build()); len(diff) != 0 {
+		t.Fatalf("Diff of identical definitions = %v, want none", diff)
+	}
+}
+
+// without a comparator, states that can't be compared with == are rejected up front
+func TestUncomparableStatesAreRejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		state statemachine.State
+	}{
+		{name: "slice", state: []string{"draft"}},
+		{name: "struct holding a slice", state: sliceState{parts: []string{"draft"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, statemachine.ErrUncomparableState) {
+					t.Fatalf("panic = %v, want ErrUncomparableState", err)
+				}
+			}()
+			statemachine.NewStateMachine(tt.state)
+		})
+	}
+}
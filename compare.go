@@ -0,0 +1,129 @@
+package statemachine
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// the identity of an edge when comparing machines. a state's transitions are deduplicated on
+// target and event, so together with the source this picks out at most one transition
+type edgeKey struct {
+	from, to State
+	event    string
+}
+
+func (k edgeKey) String() string {
+	if k.event == "" {
+		return fmt.Sprintf("%s -> %s", stateName(k.from), stateName(k.to))
+	}
+	return fmt.Sprintf("%s -> %s on %q", stateName(k.from), stateName(k.to), k.event)
+}
+
+// a registered transition along with its edge
+type edge struct {
+	key edgeKey
+	t   Transition
+}
+
+// list every registered transition with its edge. states are compared with the machine's comparator
+// rather than used as map keys, so edges are matched up with `find`
+func (sm *StateMachine) edges() []edge {
+	var edges []edge
+	for from, transitions := range sm.Transitions {
+		for _, t := range transitions {
+			edges = append(edges, edge{key: edgeKey{from: sm.stateOf(from), to: t.To, event: t.Event}, t: t})
+		}
+	}
+	return edges
+}
+
+// find the edge matching k, comparing states with the machine's comparator
+func (sm *StateMachine) find(edges []edge, k edgeKey) (Transition, bool) {
+	for _, e := range edges {
+		if sm.sameState(e.key.from, k.from) && sm.sameState(e.key.to, k.to) && e.key.event == k.event {
+			return e.t, true
+		}
+	}
+	return Transition{}, false
+}
+
+// the parent of a substate of machine m, comparing states with this machine's comparator
+func (sm *StateMachine) parentIn(m *StateMachine, child State) (State, bool) {
+	for k, parent := range m.parents {
+		if sm.sameState(m.stateOf(k), child) {
+			return parent, true
+		}
+	}
+	return nil, false
+}
+
+// report whether two machines have the same topology: the same initial state, the same substate
+// relationships, and the same transitions (compared on source, target, event, priority, whether
+// self-transitions are allowed, name, weight and metadata). guards and actions are ignored since
+// functions can't be compared, as is the current state and any other runtime bookkeeping
+func (sm *StateMachine) Equal(other *StateMachine) bool {
+	return len(sm.Diff(other)) == 0
+}
+
+// list the topological differences between this machine and `other`, as human-readable lines
+// sorted for stable output. transitions only `other` has are prefixed with "+", transitions only
+// this machine has with "-", and anything else that differs is described as a change. an empty
+// result means the machines are Equal. states are compared with this machine's comparator
+func (sm *StateMachine) Diff(other *StateMachine) []string {
+	var diff []string
+
+	if !sm.sameState(sm.InitialState, other.InitialState) {
+		diff = append(diff, fmt.Sprintf("initial state changed: %s -> %s", stateName(sm.InitialState), stateName(other.InitialState)))
+	}
+
+	ours, theirs := sm.edges(), other.edges()
+	for _, e := range ours {
+		k, t := e.key, e.t
+		o, ok := sm.find(theirs, k)
+		if !ok {
+			diff = append(diff, "- "+k.String())
+			continue
+		}
+		if t.Priority != o.Priority {
+			diff = append(diff, fmt.Sprintf("priority changed: %v: %d -> %d", k, t.Priority, o.Priority))
+		}
+		if t.AllowSelf != o.AllowSelf {
+			diff = append(diff, fmt.Sprintf("self-transition changed: %v: %t -> %t", k, t.AllowSelf, o.AllowSelf))
+		}
+		if t.Name != o.Name {
+			diff = append(diff, fmt.Sprintf("name changed: %v: %q -> %q", k, t.Name, o.Name))
+		}
+		if t.Weight != o.Weight {
+			diff = append(diff, fmt.Sprintf("weight changed: %v: %g -> %g", k, t.Weight, o.Weight))
+		}
+		if !maps.Equal(t.Metadata, o.Metadata) {
+			diff = append(diff, fmt.Sprintf("metadata changed: %v: %v -> %v", k, t.Metadata, o.Metadata))
+		}
+	}
+	for _, e := range theirs {
+		if _, ok := sm.find(ours, e.key); !ok {
+			diff = append(diff, "+ "+e.key.String())
+		}
+	}
+
+	for k, parent := range sm.parents {
+		child := sm.stateOf(k)
+		theirParent, ok := sm.parentIn(other, child)
+		switch {
+		case !ok:
+			diff = append(diff, fmt.Sprintf("- substate %s of %s", stateName(child), stateName(parent)))
+		case !sm.sameState(theirParent, parent):
+			diff = append(diff, fmt.Sprintf("parent changed: %s: %s -> %s", stateName(child), stateName(parent), stateName(theirParent)))
+		}
+	}
+	for k, parent := range other.parents {
+		child := other.stateOf(k)
+		if _, ok := sm.parentIn(sm, child); !ok {
+			diff = append(diff, fmt.Sprintf("+ substate %s of %s", stateName(child), stateName(parent)))
+		}
+	}
+
+	sort.Strings(diff)
+	return diff
+}
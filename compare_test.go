@@ -0,0 +1,80 @@
+package statemachine_test
+
+import (
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestDiff(t *testing.T) {
+	a := statemachine.NewStateMachine("Off")
+	a.AddSimpleTransition("Off", "On")
+	a.AddSimpleTransition("On", "Off")
+
+	if clone := a.Clone(); !a.Equal(clone) {
+		t.Fatalf("a machine isn't Equal to its clone: %v", a.Diff(clone))
+	}
+
+	b := statemachine.NewStateMachine("Off")
+	b.AddSimpleTransition("Off", "On")
+	b.AddSimpleTransition("On", "Broken")
+	b.AddSubstate("On", "Dimmed")
+	want := []string{"+ On -> Broken", "+ substate Dimmed of On", "- On -> Off"}
+	got := a.Diff(b)
+	if a.Equal(b) || len(got) != len(want) {
+		t.Fatalf("Diff = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Diff = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDiffTransitionFields(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(sm *statemachine.StateMachine)
+		want string
+	}{
+		{
+			name: "priority",
+			add:  func(sm *statemachine.StateMachine) { sm.AddTransitionWithPriority("Off", "On", 2, nil) },
+			want: "priority changed: Off -> On: 0 -> 2",
+		},
+		{
+			name: "name",
+			add:  func(sm *statemachine.StateMachine) { sm.AddNamedTransition("switch on", "Off", "On", nil) },
+			want: `name changed: Off -> On: "" -> "switch on"`,
+		},
+		{
+			name: "weight",
+			add:  func(sm *statemachine.StateMachine) { sm.AddWeightedTransition("Off", "On", 2.5) },
+			want: "weight changed: Off -> On: 0 -> 2.5",
+		},
+		{
+			name: "metadata",
+			add: func(sm *statemachine.StateMachine) {
+				sm.AddTransitionWithMeta("Off", "On", nil, nil, map[string]string{"role": "admin"})
+			},
+			want: "metadata changed: Off -> On: map[] -> map[role:admin]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := statemachine.NewStateMachine("Off")
+			a.AddSimpleTransition("Off", "On")
+			b := statemachine.NewStateMachine("Off")
+			tt.add(b)
+
+			got := a.Diff(b)
+			if len(got) != 1 || got[0] != tt.want {
+				t.Fatalf("Diff = %q, want [%q]", got, tt.want)
+			}
+			if a.Equal(b) {
+				t.Fatal("machines differing in a transition's " + tt.name + " are Equal")
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// set an action that undoes the side effects of a state's exit action. it runs when a transition
+// out of the state rolls back after the state's exit action already ran, i.e. when an entry or
+// post-entry action on the other side fails. the order is then:
+// exit -> (entry fails) -> exit compensation -> restore state
+// when several states were exited, their compensations run in the reverse of the exit order
+func (sm *StateMachine) SetExitCompensation(state State, comp Action) {
+	sm.exitCompensations[sm.key(state)] = comp
+}
+
+// run the compensations for the states whose exit actions ran, most recently exited first. every
+// compensation is attempted even if an earlier one fails, and the failures are joined together
+func (sm *StateMachine) compensate(exited []State) error {
+	var errs []error
+	for i := len(exited) - 1; i >= 0; i-- {
+		if comp := sm.exitCompensations[sm.key(exited[i])]; comp != nil {
+			if err := safely(comp); err != nil {
+				errs = append(errs, fmt.Errorf("compensating exit from %s failed: %v", stateName(exited[i]), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
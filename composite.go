@@ -0,0 +1,90 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownMachine is returned by Composite.TransitionAll when a target names a machine that isn't
+// part of the composite
+var ErrUnknownMachine = errors.New("unknown machine")
+
+// Composite drives several independent machines as one unit, e.g. a door lock and a light that should
+// always change together. each machine keeps its own definition and state; the composite only
+// coordinates transitions across them
+type Composite struct {
+	names    []string // the machines' names, in the order they were added
+	machines map[string]*StateMachine
+}
+
+// create an empty composite
+func NewComposite() *Composite {
+	return &Composite{machines: make(map[string]*StateMachine)}
+}
+
+// add a machine to the composite under a name, replacing any machine already added under that name
+func (c *Composite) Add(name string, sm *StateMachine) {
+	if _, exists := c.machines[name]; !exists {
+		c.names = append(c.names, name)
+	}
+	c.machines[name] = sm
+}
+
+// return the named machine, or nil if there isn't one
+func (c *Composite) Machine(name string) *StateMachine {
+	return c.machines[name]
+}
+
+// return the current state of every machine, keyed by name
+func (c *Composite) States() map[string]State {
+	states := make(map[string]State, len(c.machines))
+	for name, sm := range c.machines {
+		states[name] = sm.current()
+	}
+	return states
+}
+
+// transition several machines together, all or nothing. targets map machine names to the state each
+// should move to, and machines left out stay where they are. the transitions run in the order the
+// machines were added; if one fails, every machine already moved is put back in the state it started
+// in, in reverse order, and the failure is returned.
+//
+// as with Sequence, only the state is restored: actions that already ran are not undone, and no
+// actions run while restoring unless a machine's rollback mode is RollbackWithReentry
+func (c *Composite) TransitionAll(targets map[string]State) error {
+	for name := range targets {
+		if _, ok := c.machines[name]; !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownMachine, name)
+		}
+	}
+
+	type move struct {
+		sm   *StateMachine
+		from State
+	}
+	var moved []move
+
+	for _, name := range c.names {
+		to, ok := targets[name]
+		if !ok {
+			continue
+		}
+
+		sm := c.machines[name]
+		from := sm.current()
+		err := sm.Transition(to)
+		if err == nil {
+			moved = append(moved, move{sm: sm, from: from})
+			continue
+		}
+
+		err = fmt.Errorf("machine %q: %w", name, err)
+		for i := len(moved) - 1; i >= 0; i-- {
+			m := moved[i]
+			err = m.sm.revert(m.from, err)
+			m.sm.armTimeout(m.from)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestComposite(t *testing.T) {
+	newLock := func() *statemachine.StateMachine {
+		sm := statemachine.NewStateMachine("Locked")
+		sm.AddBidirectional("Locked", "Unlocked")
+		return sm
+	}
+	newLight := func() *statemachine.StateMachine {
+		sm := statemachine.NewStateMachine("Off")
+		sm.AddBidirectional("Off", "On")
+		return sm
+	}
+
+	tests := []struct {
+		name    string
+		targets map[string]statemachine.State
+		wantErr error
+		want    map[string]statemachine.State
+	}{
+		{
+			name:    "every machine moves",
+			targets: map[string]statemachine.State{"lock": "Unlocked", "light": "On"},
+			want:    map[string]statemachine.State{"lock": "Unlocked", "light": "On"},
+		},
+		{
+			name:    "machines left out stay put",
+			targets: map[string]statemachine.State{"light": "On"},
+			want:    map[string]statemachine.State{"lock": "Locked", "light": "On"},
+		},
+		{
+			name:    "one failure puts everything back",
+			targets: map[string]statemachine.State{"lock": "Unlocked", "light": "Dimmed"},
+			wantErr: statemachine.ErrInvalidTransition,
+			want:    map[string]statemachine.State{"lock": "Locked", "light": "Off"},
+		},
+		{
+			name:    "unknown machine",
+			targets: map[string]statemachine.State{"lock": "Unlocked", "alarm": "Armed"},
+			wantErr: statemachine.ErrUnknownMachine,
+			want:    map[string]statemachine.State{"lock": "Locked", "light": "Off"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := statemachine.NewComposite()
+			c.Add("lock", newLock())
+			c.Add("light", newLight())
+
+			if err := c.TransitionAll(tt.targets); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TransitionAll error = %v, want %v", err, tt.wantErr)
+			}
+			got := c.States()
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Fatalf("states = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
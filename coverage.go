@@ -0,0 +1,50 @@
+package statemachine
+
+// start recording which transitions are taken, so a test suite can check that it exercises the whole
+// graph with CoverageReport. only transitions taken from now on are recorded, and enabling coverage
+// again starts over
+func (sm *StateMachine) EnableCoverage() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.coverage = map[[2]State]bool{}
+}
+
+// report how many of the defined transitions have been taken since coverage was enabled, out of how
+// many there are, along with the ones never taken in registration order. transitions are counted by
+// their source and target, so several transitions between the same two states (e.g. for different
+// events) count as one, and a transition inherited from a parent state or registered from AnyState is
+// covered under the state it was registered on
+func (sm *StateMachine) CoverageReport() (covered, total int, missing [][2]State) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	seen := map[[2]State]bool{}
+	for _, t := range sm.orderedTransitions() {
+		edge, k := [2]State{t.From, t.To}, sm.coverageKey(t)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		total++
+		if sm.coverage[k] {
+			covered++
+		} else {
+			missing = append(missing, edge)
+		}
+	}
+	return covered, total, missing
+}
+
+// note that a transition was taken, if coverage is enabled
+func (sm *StateMachine) recordCoverage(t Transition) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.coverage != nil {
+		sm.coverage[sm.coverageKey(t)] = true
+	}
+}
+
+// the key a transition's source and target are stored under in the coverage tables
+func (sm *StateMachine) coverageKey(t Transition) [2]State {
+	return [2]State{sm.key(t.From), sm.key(t.To)}
+}
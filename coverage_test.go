@@ -0,0 +1,39 @@
+package statemachine_test
+
+import (
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestCoverageReport(t *testing.T) {
+	sm := newOrderMachine()
+	sm.EnableCoverage()
+
+	for _, to := range []statemachine.State{"Paid", "Shipped", "Delivered"} {
+		if err := sm.Transition(to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sm.Transition("Created") // rejected, so it covers nothing
+
+	covered, total, missing := sm.CoverageReport()
+	if covered != 3 || total != 6 {
+		t.Fatalf("CoverageReport = %d of %d, want 3 of 6", covered, total)
+	}
+	want := [][2]statemachine.State{{"Created", "Cancelled"}, {"Paid", "Cancelled"}, {"Refunded", "Created"}}
+	if !reflect.DeepEqual(missing, want) {
+		t.Fatalf("missing = %v, want %v", missing, want)
+	}
+}
+
+func TestCoverageDisabled(t *testing.T) {
+	sm := newOrderMachine()
+	if err := sm.Transition("Paid"); err != nil {
+		t.Fatal(err)
+	}
+	if covered, total, _ := sm.CoverageReport(); covered != 0 || total != 6 {
+		t.Fatalf("CoverageReport = %d of %d, want 0 of 6", covered, total)
+	}
+}
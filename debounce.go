@@ -0,0 +1,12 @@
+package statemachine
+
+import "time"
+
+// set the entry action for a state so that it runs at most once per `d`, however often the state is
+// entered. entries within the window still change the state as usual, they just skip the action.
+// the window starts from the last time the action ran successfully. registering the state's entry
+// action again with `SetEntryAction` removes the debounce
+func (sm *StateMachine) SetEntryActionDebounced(state State, action Action, d time.Duration) {
+	sm.entryActions[sm.key(state)] = action
+	sm.debounces[sm.key(state)] = d
+}
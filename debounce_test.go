@@ -0,0 +1,54 @@
+package statemachine_test
+
+import (
+	"testing"
+	"time"
+
+	statemachine "github.com/jwald3/lollipop"
+	"github.com/jwald3/lollipop/statemachinetest"
+)
+
+func TestSetEntryActionDebounced(t *testing.T) {
+	clock := statemachinetest.NewFakeClock(time.Unix(0, 0))
+	sm := statemachine.NewStateMachine("Idle")
+	sm.SetClock(clock)
+	sm.AddBidirectional("Idle", "Alerting")
+
+	alerts := 0
+	sm.SetEntryActionDebounced("Alerting", func() error {
+		alerts++
+		return nil
+	}, time.Minute)
+
+	enter := func() {
+		t.Helper()
+		if err := sm.Transition("Alerting"); err != nil {
+			t.Fatal(err)
+		}
+		if err := sm.Transition("Idle"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	enter()
+	clock.Advance(30 * time.Second)
+	enter()
+	if alerts != 1 {
+		t.Fatalf("entry action ran %d times within the window, want 1", alerts)
+	}
+
+	clock.Advance(30 * time.Second)
+	enter()
+	if alerts != 2 {
+		t.Fatalf("entry action ran %d times after the window, want 2", alerts)
+	}
+
+	sm.SetEntryAction("Alerting", func() error {
+		alerts++
+		return nil
+	})
+	enter()
+	if alerts != 3 {
+		t.Fatalf("SetEntryAction didn't remove the debounce: %d runs, want 3", alerts)
+	}
+}
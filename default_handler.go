@@ -0,0 +1,38 @@
+package statemachine
+
+import "fmt"
+
+// set a handler for transitions that have no matching edge, e.g. routing them to an "Error" state
+// instead of failing. when `Transition` or `TransitionWith` can't find a transition from the current
+// state to the requested one, the handler is called with both states. if it returns a state, the
+// machine moves there as if through a plain, unguarded transition - without needing an edge to it,
+// but otherwise held to the same rules: the target's entry guard must pass, it can't be the current
+// state, and in strict mode it must be registered. exit and entry actions run and the move is
+// recorded as usual. if it returns an error, that error is returned as-is and the machine stays put.
+// returning (nil, nil) declines to step in, and the usual ErrInvalidTransition is returned. guard
+// failures aren't routed to the handler, since an edge exists. passing nil removes the handler
+func (sm *StateMachine) SetDefaultHandler(fn func(from, to State) (State, error)) {
+	sm.defaultHandler = fn
+}
+
+// hand an unmatched transition to the default handler and carry out its decision, returning the
+// state to report the attempt against. `noMatch` is why the transition didn't match, returned if the
+// handler declines
+func (sm *StateMachine) fallBack(from, to State, payload any, noMatch error) (State, error) {
+	target, err := sm.defaultHandler(from, to)
+	if err != nil {
+		return to, err
+	}
+	if target == nil {
+		return to, noMatch
+	}
+
+	t := Transition{From: from, To: target}
+	if !sm.usable(target) {
+		return target, fmt.Errorf("%w: default handler chose %s, which is not registered", ErrUnknownState, stateName(target))
+	}
+	if err := sm.admit(t, payload); err != nil {
+		return target, err
+	}
+	return target, sm.execute(t, execution{payload: payload})
+}
@@ -0,0 +1,103 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestDefaultHandler(t *testing.T) {
+	errRefused := errors.New("refused")
+
+	tests := []struct {
+		name    string
+		handler func(from, to statemachine.State) (statemachine.State, error)
+		setup   func(sm *statemachine.StateMachine)
+		wantErr error
+		want    statemachine.State
+	}{
+		{
+			name:    "redirects to an error state",
+			handler: func(from, to statemachine.State) (statemachine.State, error) { return "Error", nil },
+			want:    "Error",
+		},
+		{
+			name:    "declines with an error",
+			handler: func(from, to statemachine.State) (statemachine.State, error) { return nil, errRefused },
+			wantErr: errRefused,
+			want:    "Draft",
+		},
+		{
+			name:    "declines without an error",
+			handler: func(from, to statemachine.State) (statemachine.State, error) { return nil, nil },
+			wantErr: statemachine.ErrInvalidTransition,
+			want:    "Draft",
+		},
+		{
+			name:    "redirects to the current state",
+			handler: func(from, to statemachine.State) (statemachine.State, error) { return from, nil },
+			wantErr: statemachine.ErrSelfTransition,
+			want:    "Draft",
+		},
+		{
+			name:    "redirects past an entry guard",
+			handler: func(from, to statemachine.State) (statemachine.State, error) { return "Error", nil },
+			setup: func(sm *statemachine.StateMachine) {
+				sm.SetEntryGuard("Error", func() bool { return false })
+			},
+			wantErr: statemachine.ErrGuardFailed,
+			want:    "Draft",
+		},
+		{
+			name:    "redirects to an unregistered state in strict mode",
+			handler: func(from, to statemachine.State) (statemachine.State, error) { return "Eror", nil },
+			setup: func(sm *statemachine.StateMachine) {
+				sm.StrictStates(true)
+			},
+			wantErr: statemachine.ErrUnknownState,
+			want:    "Draft",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Draft")
+			sm.RegisterState("Review")
+			sm.RegisterState("Error")
+			sm.AddSimpleTransition("Draft", "Review")
+			if tt.setup != nil {
+				tt.setup(sm)
+			}
+			sm.SetDefaultHandler(tt.handler)
+
+			err := sm.Transition("Published")
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Transition: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition error = %v, want %v", err, tt.wantErr)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultHandlerSkipsGuardFailures(t *testing.T) {
+	called := false
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddTransition("Draft", "Review", func() bool { return false }, nil)
+	sm.SetDefaultHandler(func(from, to statemachine.State) (statemachine.State, error) {
+		called = true
+		return "Error", nil
+	})
+
+	if err := sm.Transition("Review"); !errors.Is(err, statemachine.ErrGuardFailed) {
+		t.Fatalf("Transition error = %v, want ErrGuardFailed", err)
+	}
+	if called {
+		t.Fatal("default handler called for a guard failure")
+	}
+}
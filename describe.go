@@ -0,0 +1,96 @@
+package statemachine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Description is a JSON-friendly view of a state machine. States are rendered with fmt so
+// that any state type can be described, regardless of how (or whether) it marshals itself
+type Description struct {
+	Initial     string              `json:"initial"`
+	Current     string              `json:"current"`
+	Transitions map[string][]string `json:"transitions"`
+}
+
+// PatchOperation is a single RFC 6902 JSON patch operation against a Description document
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitempty"`
+}
+
+// build a description of the machine's topology and its current position
+func (sm *StateMachine) Describe() Description {
+	transitions := make(map[string][]string, len(sm.Transitions))
+	for from, ts := range sm.Transitions {
+		targets := make([]string, 0, len(ts))
+		for _, t := range ts {
+			targets = append(targets, stateName(t.To))
+		}
+		transitions[stateName(sm.stateOf(from))] = targets
+	}
+
+	return Description{
+		Initial:     stateName(sm.InitialState),
+		Current:     stateName(sm.current()),
+		Transitions: transitions,
+	}
+}
+
+// summarize the machine for debugging, e.g. in logs or a REPL:
+//
+//	current: On, initial: Off; Off -> [On]; > On -> [Off, Broken (guarded)]
+//
+// the current state's transitions are marked with ">", wildcard transitions are listed under "*", and
+// guarded transitions are marked "(guarded)". this is meant for people - use Describe or one of the
+// exporters for anything a program needs to read
+func (sm *StateMachine) String() string {
+	current := sm.current()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "current: %s, initial: %s", stateName(current), stateName(sm.InitialState))
+	for _, from := range sm.sources() {
+		name := stateName(from)
+		if sm.sameState(from, AnyState) {
+			name = "*"
+		}
+
+		transitions := sm.Transitions[sm.key(from)]
+		targets := make([]string, 0, len(transitions))
+		for _, t := range transitions {
+			target := stateName(t.To)
+			if t.guarded() {
+				target += " (guarded)"
+			}
+			targets = append(targets, target)
+		}
+
+		b.WriteString("; ")
+		if sm.sameState(from, current) {
+			b.WriteString("> ")
+		}
+		fmt.Fprintf(&b, "%s -> [%s]", name, strings.Join(targets, ", "))
+	}
+	return b.String()
+}
+
+// render the machine's description as JSON, suitable for sending to a frontend wholesale
+func (sm *StateMachine) DescribeJSON() ([]byte, error) {
+	return json.Marshal(sm.Describe())
+}
+
+// compute the JSON patch (RFC 6902) that brings a description taken at the time of `previous`
+// up to date with the machine's current position. the patch only ever touches the runtime
+// fields of the description, so a client holding a full description from DescribeJSON can apply
+// it incrementally instead of refetching everything. an empty patch means nothing has moved
+func (sm *StateMachine) StateDelta(previous Snapshot) ([]byte, error) {
+	patch := []PatchOperation{}
+
+	if current := sm.current(); !sm.sameState(previous.State, current) {
+		patch = append(patch, PatchOperation{Op: "replace", Path: "/current", Value: stateName(current)})
+	}
+
+	return json.Marshal(patch)
+}
@@ -0,0 +1,68 @@
+package statemachine_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func newSwitch() *statemachine.StateMachine {
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddSimpleTransition("Off", "On")
+	sm.AddSimpleTransition("On", "Off")
+	sm.AddTransition("On", "Broken", func() bool { return false }, nil)
+	return sm
+}
+
+func TestDescribeJSON(t *testing.T) {
+	sm := newSwitch()
+	sm.Transition("On")
+
+	data, err := sm.DescribeJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got statemachine.Description
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := statemachine.Description{
+		Initial:     "Off",
+		Current:     "On",
+		Transitions: map[string][]string{"Off": {"On"}, "On": {"Off", "Broken"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("description = %+v, want %+v", got, want)
+	}
+}
+
+func TestStateDelta(t *testing.T) {
+	sm := newSwitch()
+	before := sm.Snapshot()
+
+	if delta, err := sm.StateDelta(before); err != nil || string(delta) != "[]" {
+		t.Fatalf("StateDelta without moving = %s, %v, want []", delta, err)
+	}
+
+	sm.Transition("On")
+	delta, err := sm.StateDelta(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"op":"replace","path":"/current","value":"On"}]`; string(delta) != want {
+		t.Fatalf("StateDelta = %s, want %s", delta, want)
+	}
+}
+
+func TestString(t *testing.T) {
+	sm := newSwitch()
+	sm.Transition("On")
+	sm.AddTransition(statemachine.AnyState, "Off", nil, nil)
+
+	want := "current: On, initial: Off; Off -> [On]; > On -> [Off, Broken (guarded)]; * -> [Off]"
+	if got := sm.String(); got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package statemachine
+
+// check whether a transition would succeed and describe what it would run, without running anything
+// or changing state. the transition is matched, its guards evaluated and the transition budget checked
+// exactly as `Transition` would, so an invalid transition returns the same error. on success, the returned steps list the actions
+// that would execute, in order, each named after the state or edge it belongs to:
+//
+//	prepare:<state>      a two-phase action being prepared
+//	exit:<state>         the exit action of the state being left
+//	action:<from>-><to>  the transition's own action
+//	entry:<state>        the entry action of the state being entered
+//	post-entry:<state>   the post-entry action of the state being entered
+//	reentry:<state>      the reentry action run by a self-transition, in place of exit and entry actions
+//
+// before-transition hooks and the default handler are not consulted, since they're free to have side
+// effects of their own. a transition the default handler would redirect is reported as invalid
+func (sm *StateMachine) DryRun(to State) (willRun []string, err error) {
+	from := sm.current()
+	t, err := sm.match(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := sm.admit(t, nil); err != nil {
+		return nil, err
+	}
+	if err := sm.checkBudget(from, to); err != nil {
+		return nil, err
+	}
+
+	willRun = []string{}
+	if sm.twoPhaseActions[sm.key(from)] != nil {
+		willRun = append(willRun, "prepare:"+stateName(from))
+	}
+	if !sm.sameState(from, to) && sm.twoPhaseActions[sm.key(to)] != nil {
+		willRun = append(willRun, "prepare:"+stateName(to))
+	}
+	if sm.reentryActionFor(from, to) != nil {
+		if t.hasAction() {
+			willRun = append(willRun, "action:"+stateName(from)+"->"+stateName(to))
+		}
+		return append(willRun, "reentry:"+stateName(to)), nil
+	}
+
+	for _, s := range sm.exitChain(from, to) {
+		if sm.exitActions[sm.key(s)] != nil {
+			willRun = append(willRun, "exit:"+stateName(s))
+		}
+	}
+	if t.hasAction() {
+		willRun = append(willRun, "action:"+stateName(from)+"->"+stateName(to))
+	}
+	entering := sm.entryChain(from, to)
+	for _, s := range entering {
+		if sm.entryActions[sm.key(s)] != nil || sm.entryActionFrom(from, s) != nil {
+			willRun = append(willRun, "entry:"+stateName(s))
+		}
+	}
+	for _, s := range entering {
+		if sm.postEntryActions[sm.key(s)] != nil {
+			willRun = append(willRun, "post-entry:"+stateName(s))
+		}
+	}
+	return willRun, nil
+}
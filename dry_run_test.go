@@ -0,0 +1,91 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestDryRun(t *testing.T) {
+	noop := func() error { return nil }
+
+	tests := []struct {
+		name    string
+		setup   func(sm *statemachine.StateMachine)
+		to      statemachine.State
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "lists the actions in order",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddTransition("Draft", "Review", nil, noop)
+				sm.SetExitAction("Draft", noop)
+				sm.SetEntryAction("Review", noop)
+			},
+			to:   "Review",
+			want: []string{"exit:Draft", "action:Draft->Review", "entry:Review"},
+		},
+		{
+			name:    "undefined transition",
+			setup:   func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Draft", "Review") },
+			to:      "Published",
+			wantErr: statemachine.ErrInvalidTransition,
+		},
+		{
+			name: "failing guard",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddTransition("Draft", "Review", func() bool { return false }, nil)
+			},
+			to:      "Review",
+			wantErr: statemachine.ErrGuardFailed,
+		},
+		{
+			name: "budget used up",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Draft", "Review").AddSimpleTransition("Review", "Draft")
+				sm.SetMaxTransitions(1)
+				sm.Transition("Review")
+			},
+			to:      "Draft",
+			wantErr: statemachine.ErrStepBudgetExceeded,
+		},
+		{
+			name: "default handler isn't consulted",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Draft", "Review")
+				sm.SetDefaultHandler(func(from, to statemachine.State) (statemachine.State, error) {
+					t.Fatal("default handler called by DryRun")
+					return nil, nil
+				})
+			},
+			to:      "Published",
+			wantErr: statemachine.ErrInvalidTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Draft")
+			tt.setup(sm)
+			before := sm.CurrentState()
+
+			got, err := sm.DryRun(tt.to)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("DryRun: %v", err)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("steps = %v, want %v", got, tt.want)
+			}
+			if sm.CurrentState() != before {
+				t.Fatalf("DryRun moved the machine from %v to %v", before, sm.CurrentState())
+			}
+		})
+	}
+}
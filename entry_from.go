@@ -0,0 +1,42 @@
+package statemachine
+
+// the pair of states a source-specific entry action is registered for
+type entryPair struct {
+	from, to State
+}
+
+// set an entry action that only runs when `to` is entered from `from`, e.g. resuming work when
+// "Active" is entered from "Paused" but starting fresh when it's entered from "New". when a
+// source-specific action is registered for the state being left, it runs instead of the generic
+// action set with SetEntryAction; otherwise the generic action runs as usual. source-specific actions
+// are never debounced
+func (sm *StateMachine) SetEntryActionFrom(from, to State, action Action) {
+	sm.entryActionsFrom[entryPair{from: sm.key(from), to: sm.key(to)}] = action
+}
+
+// the source-specific entry action for entering `to` from `from`, if there is one
+func (sm *StateMachine) entryActionFrom(from, to State) Action {
+	return sm.entryActionsFrom[entryPair{from: sm.key(from), to: sm.key(to)}]
+}
+
+// run the entry action for entering `state` from `from`, preferring a source-specific action. a state
+// implementing EntryValidator is validated first
+func (sm *StateMachine) enter(from, state State) error {
+	if err := validateEntry(state); err != nil {
+		return err
+	}
+	if action := sm.entryActionFrom(from, state); action != nil {
+		return sm.timed(state, action)
+	}
+	return sm.runEntryAction(state)
+}
+
+// report whether any source-specific entry action is registered for entering a state
+func (sm *StateMachine) hasEntryActionsFrom(s State) bool {
+	for pair, action := range sm.entryActionsFrom {
+		if pair.to == sm.key(s) && action != nil {
+			return true
+		}
+	}
+	return false
+}
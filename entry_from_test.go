@@ -0,0 +1,36 @@
+package statemachine_test
+
+import (
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestSetEntryActionFrom(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    statemachine.State
+		wantLog []string
+	}{
+		{name: "source-specific action", from: "Paused", wantLog: []string{"resume"}},
+		{name: "generic action otherwise", from: "New", wantLog: []string{"start"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			sm := statemachine.NewStateMachine(tt.from)
+			sm.AddSimpleTransition(tt.from, "Active")
+			sm.SetEntryAction("Active", logged(&log, "start"))
+			sm.SetEntryActionFrom("Paused", "Active", logged(&log, "resume"))
+
+			if err := sm.Transition("Active"); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(log, tt.wantLog) {
+				t.Fatalf("ran %v, want %v", log, tt.wantLog)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package statemachine
+
+// set a guard on entering a state, e.g. only allowing "Shipped" once inventory is reserved, no matter
+// which state the machine is coming from. it's checked after the matched transition's own guards and
+// before any action runs, so a failing entry guard rejects the transition with ErrGuardFailed and leaves
+// the machine untouched. only the target state's guard is checked - parent states entered on the way
+// into a substate are not. passing nil removes the guard
+func (sm *StateMachine) SetEntryGuard(state State, guard Guard) {
+	if guard == nil {
+		delete(sm.entryGuards, sm.key(state))
+		return
+	}
+	sm.entryGuards[sm.key(state)] = guard
+}
+
+// report whether the target state's entry guard, if it has one, allows entering it
+func (sm *StateMachine) entryAllowed(to State) bool {
+	guard := sm.entryGuards[sm.key(to)]
+	return guard == nil || guard()
+}
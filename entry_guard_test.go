@@ -0,0 +1,43 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestSetEntryGuard(t *testing.T) {
+	reserved := false
+	exited := false
+	sm := statemachine.NewStateMachine("Packing")
+	sm.AddSimpleTransition("Packing", "Shipped")
+	sm.AddTransition(statemachine.AnyState, "Shipped", nil, nil)
+	sm.SetExitAction("Packing", func() error {
+		exited = true
+		return nil
+	})
+	sm.SetEntryGuard("Shipped", func() bool { return reserved })
+
+	if err := sm.Transition("Shipped"); !errors.Is(err, statemachine.ErrGuardFailed) {
+		t.Fatalf("Transition error = %v, want ErrGuardFailed", err)
+	}
+	if exited {
+		t.Fatal("exit action ran for a transition the entry guard rejected")
+	}
+	if got := sm.AvailableTransitions(); len(got) != 0 {
+		t.Fatalf("AvailableTransitions = %v, want none while the entry guard fails", got)
+	}
+
+	reserved = true
+	if err := sm.Transition("Shipped"); err != nil {
+		t.Fatal(err)
+	}
+
+	sm.Reset()
+	reserved = false
+	sm.SetEntryGuard("Shipped", nil)
+	if err := sm.Transition("Shipped"); err != nil {
+		t.Fatalf("removed entry guard still applies: %v", err)
+	}
+}
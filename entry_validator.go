@@ -0,0 +1,18 @@
+package statemachine
+
+// EntryValidator can be implemented by a state type to check itself whenever it's entered, without
+// registering an entry guard for every state. ValidateEntry runs once the machine has moved into the
+// state and before its entry action, so a non-nil error rolls the transition back and is reported
+// with ErrEntryActionFailed, just like a failing entry action. parent states entered on the way into
+// a substate are validated too, outermost first
+type EntryValidator interface {
+	ValidateEntry() error
+}
+
+// run the state's own validation, if it has any
+func validateEntry(s State) error {
+	if v, ok := s.(EntryValidator); ok {
+		return safely(v.ValidateEntry)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+// a state that refuses to be entered while its quota is used up
+type quotaState struct {
+	name string
+	full bool
+}
+
+func (q *quotaState) ValidateEntry() error {
+	if q.full {
+		return errors.New(q.name + " is full")
+	}
+	return nil
+}
+
+func TestEntryValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		full    bool
+		wantErr error
+		wantLog []string
+	}{
+		{name: "valid", wantLog: []string{"enter Queue"}},
+		{name: "invalid", full: true, wantErr: statemachine.ErrEntryActionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			queue := &quotaState{name: "Queue", full: tt.full}
+			sm := statemachine.NewStateMachine("Idle")
+			sm.AddSimpleTransition("Idle", queue)
+			sm.SetEntryAction(queue, logged(&log, "enter Queue"))
+
+			if err := sm.Transition(queue); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition = %v, want %v", err, tt.wantErr)
+			}
+			want := statemachine.State(queue)
+			if tt.wantErr != nil {
+				want = "Idle"
+			}
+			if got := sm.CurrentState(); got != want {
+				t.Fatalf("state = %v, want %v", got, want)
+			}
+			if len(log) != len(tt.wantLog) {
+				t.Fatalf("actions = %v, want %v", log, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestEntryValidatorOnReset(t *testing.T) {
+	start := &quotaState{name: "Start"}
+	sm := statemachine.NewStateMachine(start)
+	sm.AddSimpleTransition(start, "Done")
+	if err := sm.Transition("Done"); err != nil {
+		t.Fatal(err)
+	}
+
+	start.full = true
+	if err := sm.ResetWithEntry(); !errors.Is(err, statemachine.ErrEntryActionFailed) {
+		t.Fatalf("ResetWithEntry = %v, want ErrEntryActionFailed", err)
+	}
+	if got := sm.CurrentState(); got != "Done" {
+		t.Fatalf("state = %v, want Done", got)
+	}
+}
@@ -0,0 +1,112 @@
+package statemachine
+
+import "fmt"
+
+// rejection explains why a transition could not be taken, without the cost of building an error.
+// callers that do need an error turn it into one with `err`
+type rejection int
+
+const (
+	accepted            rejection = iota
+	rejectNoTransitions           // the state has no transitions at all
+	rejectNoMatch                 // the state has no transition to the requested target
+	rejectSelf                    // the transition would be a self-transition that isn't allowed
+	rejectGuard                   // the transition's guard is not satisfied
+	rejectEntryGuard              // the target state's entry guard is not satisfied
+)
+
+// the error describing a rejected transition between two states
+func (r rejection) err(from, to State) error {
+	switch r {
+	case accepted:
+		return nil
+	case rejectSelf:
+		return fmt.Errorf("%w: %s", ErrSelfTransition, stateName(from))
+	case rejectGuard, rejectEntryGuard:
+		return &TransitionError{From: from, To: to, Reason: r.reason(), Err: ErrGuardFailed}
+	default:
+		return &TransitionError{From: from, To: to, Reason: r.reason()}
+	}
+}
+
+// a short human-readable explanation of the rejection
+func (r rejection) reason() string {
+	switch r {
+	case accepted:
+		return ""
+	case rejectNoTransitions:
+		return "no transitions defined from this state"
+	case rejectSelf:
+		return "self-transition not allowed"
+	case rejectGuard:
+		return "the transition's guard was not satisfied"
+	case rejectEntryGuard:
+		return "the target state's entry guard was not satisfied"
+	default:
+		return "no transition defined to this state"
+	}
+}
+
+// the error describing a rejected transition, wrapping the error a guard gave for rejecting it if
+// there was one so that callers can see why
+func (r rejection) errWith(guardErr error, from, to State) error {
+	if guardErr != nil {
+		return &TransitionError{From: from, To: to, Reason: r.reason(), Err: ErrGuardFailed, Cause: guardErr}
+	}
+	return r.err(from, to)
+}
+
+// TransitionError describes a transition that was rejected, so callers can read which states were
+// involved and why instead of parsing the message:
+//
+//	var te *statemachine.TransitionError
+//	if errors.As(err, &te) {
+//		fmt.Printf("can't go from %v to %v: %s\n", te.From, te.To, te.Reason)
+//	}
+//
+// It unwraps to ErrGuardFailed when a matching transition exists but its guard wasn't satisfied, and to
+// ErrInvalidTransition when no matching transition is defined at all. When a GuardErr guard gave an error
+// for rejecting the transition it's kept in Cause and unwraps too, so errors.Is and errors.As reach it.
+type TransitionError struct {
+	From   State  // the state the machine was in
+	To     State  // the state it was asked to move to
+	Reason string // a short human-readable explanation of the rejection
+	Err    error  // the sentinel describing the kind of rejection, ErrInvalidTransition if nil
+	Cause  error  // the error the guard gave for rejecting the transition, if any
+}
+
+func (e *TransitionError) Error() string {
+	msg := fmt.Sprintf("%v: from %s to %s", e.kind(), stateName(e.From), stateName(e.To))
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+func (e *TransitionError) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.kind(), e.Cause}
+	}
+	return []error{e.kind()}
+}
+
+// the sentinel describing the kind of rejection
+func (e *TransitionError) kind() error {
+	if e.Err != nil {
+		return e.Err
+	}
+	return ErrInvalidTransition
+}
+
+// the name a state is shown by in errors and exported output. a state implementing fmt.Stringer is
+// always shown through its String method - even if it also implements error, which fmt would otherwise
+// prefer - and anything else is formatted with %v
+func stateName(s State) string {
+	if stringer, ok := s.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprint(s)
+}
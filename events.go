@@ -0,0 +1,107 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrNoTransitionForEvent is returned by Fire when the current state has no transition for the event
+var ErrNoTransitionForEvent = errors.New("no transition for event")
+
+// register a transition that is triggered by a named event rather than by naming the target state.
+// the transition behaves like any other once triggered, and can still be reached through `Transition`
+func (sm *StateMachine) AddEventTransition(from State, event string, to State) {
+	sm.addTransition(Transition{
+		From:  from,
+		To:    to,
+		Event: event,
+	})
+}
+
+// register several event-triggered transitions from one state, mapping each event to its target.
+// they're registered in order of event name, so the result doesn't depend on map iteration order.
+// an event that already has a transition from `from` is reported as ErrInvalidDefinition, in which
+// case nothing is registered
+func (sm *StateMachine) AddEventTransitions(from State, events map[string]State) error {
+	names := make([]string, 0, len(events))
+	for event := range events {
+		names = append(names, event)
+	}
+	sort.Strings(names)
+
+	for _, t := range sm.Transitions[sm.key(from)] {
+		if _, ok := events[t.Event]; ok && t.Event != "" {
+			return fmt.Errorf("%w: event %q is already registered from %s", ErrInvalidDefinition, t.Event, stateName(from))
+		}
+	}
+
+	for _, event := range names {
+		sm.AddEventTransition(from, event, events[event])
+	}
+	return nil
+}
+
+// trigger the transition registered for the given event from the current state, running its guard
+// and the usual exit, transition, and entry actions. like Transition, it waits for any transition in
+// progress, so the machine's own callbacks must queue the transition with QueueTransition instead
+func (sm *StateMachine) Fire(event string) error {
+	sm.beginTransition()
+	from := sm.current()
+	to, err := sm.fire(from, event)
+	sm.endTransition()
+	return sm.report(from, to, err)
+}
+
+// the body of Fire, returning the target the attempt should be reported against
+func (sm *StateMachine) fire(from State, event string) (State, error) {
+	// when several transitions share the event, they're tried in priority order and the first one
+	// whose guard passes is taken. if none of them pass, the first one's rejection is reported
+	var rejected error
+	var rejectedTo State
+	for _, t := range sm.candidates(from) {
+		if t.Event != event {
+			continue
+		}
+		if r, guardErr := sm.inspect(t, from, nil); r != accepted {
+			if rejected == nil {
+				rejected, rejectedTo = r.errWith(guardErr, from, t.To), t.To
+			}
+			continue
+		}
+		return t.To, sm.execute(t, execution{})
+	}
+
+	if rejected != nil {
+		return rejectedTo, rejected
+	}
+
+	// there's no target to speak of when the event isn't recognised
+	return nil, fmt.Errorf("%w: %q from %s", ErrNoTransitionForEvent, event, stateName(from))
+}
+
+// list the events that have a transition defined from the current state, in registration order.
+// guards are not evaluated, so firing one of these events may still be rejected
+func (sm *StateMachine) PossibleEvents() []string {
+	var events []string
+	seen := map[string]bool{}
+	for _, t := range sm.candidates(sm.current()) {
+		if t.Event != "" && !seen[t.Event] {
+			seen[t.Event] = true
+			events = append(events, t.Event)
+		}
+	}
+	return events
+}
+
+// fire each event in order, stopping at the first one that can't be handled. the returned error names
+// the event that failed and the state the machine had reached, and wraps the underlying error. events
+// fired before the failure are not undone
+func (sm *StateMachine) FireSequence(events ...string) error {
+	for i, event := range events {
+		if err := sm.Fire(event); err != nil {
+			return fmt.Errorf("event %q (%d of %d) failed in state %s: %w", event, i+1, len(events), stateName(sm.current()), err)
+		}
+	}
+	return nil
+}
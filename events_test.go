@@ -0,0 +1,104 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func newTicketMachine() *statemachine.StateMachine {
+	sm := statemachine.NewStateMachine("Open")
+	sm.AddEventTransition("Open", "start", "InProgress")
+	sm.AddEventTransition("Open", "close", "Closed")
+	sm.AddEventTransition("InProgress", "resolve", "Resolved")
+	sm.AddEventTransition("Resolved", "close", "Closed")
+	return sm
+}
+
+func TestFire(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   string
+		wantErr error
+		want    statemachine.State
+	}{
+		{name: "known event", event: "start", want: "InProgress"},
+		{name: "event shared with another state", event: "close", want: "Closed"},
+		{name: "event not defined here", event: "resolve", wantErr: statemachine.ErrNoTransitionForEvent, want: "Open"},
+		{name: "unknown event", event: "explode", wantErr: statemachine.ErrNoTransitionForEvent, want: "Open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTicketMachine()
+			if err := sm.Fire(tt.event); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Fire(%q) error = %v, want %v", tt.event, err, tt.wantErr)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFireTriesEveryTransitionForTheEvent(t *testing.T) {
+	sm := statemachine.NewStateMachine("Review")
+	sm.AddEventTransition("Review", "decide", "Approved")
+	sm.AddEventTransition("Review", "decide", "Rejected")
+	sm.SetEntryGuard("Approved", func() bool { return false })
+
+	if err := sm.Fire("decide"); err != nil {
+		t.Fatal(err)
+	}
+	if got := sm.CurrentState(); got != "Rejected" {
+		t.Fatalf("state = %v, want Rejected", got)
+	}
+}
+
+func TestPossibleEvents(t *testing.T) {
+	sm := newTicketMachine()
+	if got, want := sm.PossibleEvents(), []string{"start", "close"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("PossibleEvents = %v, want %v", got, want)
+	}
+}
+
+func TestFireSequence(t *testing.T) {
+	sm := newTicketMachine()
+	if err := sm.FireSequence("start", "resolve", "close"); err != nil {
+		t.Fatal(err)
+	}
+	if got := sm.CurrentState(); got != "Closed" {
+		t.Fatalf("state = %v, want Closed", got)
+	}
+
+	sm = newTicketMachine()
+	err := sm.FireSequence("start", "close", "resolve")
+	if !errors.Is(err, statemachine.ErrNoTransitionForEvent) || !strings.Contains(err.Error(), `event "close" (2 of 3)`) {
+		t.Fatalf("FireSequence error = %v, want the second event named", err)
+	}
+	if got := sm.CurrentState(); got != "InProgress" {
+		t.Fatalf("state = %v, want InProgress", got)
+	}
+}
+
+func TestAddEventTransitions(t *testing.T) {
+	sm := statemachine.NewStateMachine("Open")
+	err := sm.AddEventTransitions("Open", map[string]statemachine.State{"start": "InProgress", "close": "Closed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sm.PossibleEvents(), []string{"close", "start"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("PossibleEvents = %v, want %v", got, want)
+	}
+
+	err = sm.AddEventTransitions("Open", map[string]statemachine.State{"start": "Closed", "hold": "OnHold"})
+	if !errors.Is(err, statemachine.ErrInvalidDefinition) {
+		t.Fatalf("re-registering an event error = %v, want ErrInvalidDefinition", err)
+	}
+	if got, want := sm.PossibleEvents(), []string{"close", "start"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("a rejected batch registered events: PossibleEvents = %v, want %v", got, want)
+	}
+}
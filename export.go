@@ -0,0 +1,154 @@
+package statemachine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// every transition in the table, grouped by source in registration order
+func (sm *StateMachine) orderedTransitions() []Transition {
+	var transitions []Transition
+	for _, from := range sm.sources() {
+		transitions = append(transitions, sm.Transitions[sm.key(from)]...)
+	}
+	return transitions
+}
+
+// DOTOptions controls what ToDOTWithOptions includes in its output
+type DOTOptions struct {
+	ShowActions  bool     // tag states that have entry actions with "E" and exit actions with "X"
+	MetadataKeys []string // metadata keys to add to transition labels as key=value, when a transition has them
+}
+
+// render the machine as a Graphviz DOT digraph, e.g. for `dot -Tsvg`. every known state is a node,
+// the initial state is marked by an arrow from a point, and transitions with an event are labelled
+// with it. wildcard transitions are drawn from a node named "*". states and transitions are listed
+// in registration order, so the output is the same every time for the same definition
+func (sm *StateMachine) ToDOT() string {
+	return sm.ToDOTWithOptions(DOTOptions{})
+}
+
+// render the machine as a Graphviz DOT digraph like ToDOT, with extra annotations as chosen by the options
+func (sm *StateMachine) ToDOTWithOptions(opts DOTOptions) string {
+	var b strings.Builder
+	b.WriteString("digraph statemachine {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\t__start [shape=point];\n")
+	for _, s := range sm.allStates() {
+		fmt.Fprintf(&b, "\t%q", stateName(s))
+		if opts.ShowActions {
+			if tags := sm.actionTags(s); tags != "" {
+				fmt.Fprintf(&b, " [label=%q]", stateName(s)+" ("+tags+")")
+			}
+		}
+		b.WriteString(";\n")
+	}
+	fmt.Fprintf(&b, "\t__start -> %q;\n", stateName(sm.InitialState))
+	for _, t := range sm.orderedTransitions() {
+		fmt.Fprintf(&b, "\t%q -> %q", stateName(t.From), stateName(t.To))
+		if label := edgeLabel(t, opts.MetadataKeys); label != "" {
+			fmt.Fprintf(&b, " [label=%q]", label)
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// the label for a transition's edge: its event, followed by any of the given metadata keys it has
+func edgeLabel(t Transition, metadataKeys []string) string {
+	var parts []string
+	if t.Event != "" {
+		parts = append(parts, t.Event)
+	}
+	for _, key := range metadataKeys {
+		if value, ok := t.Metadata[key]; ok {
+			parts = append(parts, key+"="+value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// the tags marking which kinds of side effects a state has: "E" for an entry action (including those
+// only run when coming from a particular state) and "X" for an exit action
+func (sm *StateMachine) actionTags(s State) string {
+	var tags []string
+	if sm.entryActions[sm.key(s)] != nil || sm.hasEntryActionsFrom(s) {
+		tags = append(tags, "E")
+	}
+	if sm.exitActions[sm.key(s)] != nil {
+		tags = append(tags, "X")
+	}
+	return strings.Join(tags, " ")
+}
+
+// render the machine as a Mermaid state diagram. states are given short ids and declared with their
+// names, so names containing spaces or punctuation still render. like ToDOT, the output is in
+// registration order and stable for the same definition
+func (sm *StateMachine) ToMermaid() string {
+	ids := map[State]string{}
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	declare := func(s State) {
+		id := fmt.Sprintf("s%d", len(ids))
+		ids[sm.key(s)] = id
+		fmt.Fprintf(&b, "    state %q as %s\n", stateName(s), id)
+	}
+	for _, s := range sm.allStates() {
+		declare(s)
+	}
+	if len(sm.Transitions[AnyState]) > 0 {
+		declare(AnyState)
+	}
+
+	fmt.Fprintf(&b, "    [*] --> %s\n", ids[sm.key(sm.InitialState)])
+	for _, t := range sm.orderedTransitions() {
+		fmt.Fprintf(&b, "    %s --> %s", ids[sm.key(t.From)], ids[sm.key(t.To)])
+		if t.Event != "" {
+			fmt.Fprintf(&b, " : %s", t.Event)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// the JSON document produced by ToGraphJSON
+type graphJSON struct {
+	Initial string      `json:"initial"`
+	Current string      `json:"current"`
+	States  []string    `json:"states"`
+	Edges   []graphEdge `json:"edges"`
+}
+
+type graphEdge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Guarded bool   `json:"guarded"`
+}
+
+// render the machine's topology as an adjacency list in JSON, for tooling such as graph visualizers:
+//
+//	{"initial": "...", "current": "...", "states": [...], "edges": [{"from": "...", "to": "...", "guarded": true}]}
+//
+// states are written by name, in registration order, and wildcard transitions come from "*". this
+// describes the shape of the machine rather than preserving it - use WriteSnapshot for persistence
+func (sm *StateMachine) ToGraphJSON() ([]byte, error) {
+	graph := graphJSON{
+		Initial: stateName(sm.InitialState),
+		Current: stateName(sm.current()),
+		States:  []string{},
+		Edges:   []graphEdge{},
+	}
+	for _, s := range sm.allStates() {
+		graph.States = append(graph.States, stateName(s))
+	}
+	for _, t := range sm.orderedTransitions() {
+		graph.Edges = append(graph.Edges, graphEdge{
+			From:    stateName(t.From),
+			To:      stateName(t.To),
+			Guarded: t.guarded(),
+		})
+	}
+	return json.Marshal(graph)
+}
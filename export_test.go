@@ -0,0 +1,142 @@
+package statemachine_test
+
+import (
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+// a small machine exercising every kind of edge the exporters draw
+func newExportMachine() *statemachine.StateMachine {
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddEventTransition("Draft", "submit", "Review")
+	sm.AddTransitionWithMeta("Review", "Published", func() bool { return true }, nil, map[string]string{"role": "editor"})
+	sm.AddTransition(statemachine.AnyState, "Archived", nil, nil)
+	sm.SetEntryAction("Review", func() error { return nil })
+	sm.SetExitAction("Review", func() error { return nil })
+	return sm
+}
+
+func TestToDOT(t *testing.T) {
+	want := `digraph statemachine {
+	rankdir=LR;
+	__start [shape=point];
+	"Draft";
+	"Review";
+	"Published";
+	"Archived";
+	__start -> "Draft";
+	"Draft" -> "Review" [label="submit"];
+	"Review" -> "Published";
+	"*" -> "Archived";
+}
+`
+	if got := newExportMachine().ToDOT(); got != want {
+		t.Fatalf("ToDOT =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestToDOTWithOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts statemachine.DOTOptions
+		want string
+	}{
+		{
+			name: "with actions",
+			opts: statemachine.DOTOptions{ShowActions: true},
+			want: `digraph statemachine {
+	rankdir=LR;
+	__start [shape=point];
+	"Draft";
+	"Review" [label="Review (E X)"];
+	"Published";
+	"Archived";
+	__start -> "Draft";
+	"Draft" -> "Review" [label="submit"];
+	"Review" -> "Published";
+	"*" -> "Archived";
+}
+`,
+		},
+		{
+			name: "with actions and metadata",
+			opts: statemachine.DOTOptions{ShowActions: true, MetadataKeys: []string{"role"}},
+			want: `digraph statemachine {
+	rankdir=LR;
+	__start [shape=point];
+	"Draft";
+	"Review" [label="Review (E X)"];
+	"Published";
+	"Archived";
+	__start -> "Draft";
+	"Draft" -> "Review" [label="submit"];
+	"Review" -> "Published" [label="role=editor"];
+	"*" -> "Archived";
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newExportMachine().ToDOTWithOptions(tt.opts)
+			if got != tt.want {
+				t.Fatalf("ToDOTWithOptions =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToMermaid(t *testing.T) {
+	want := `stateDiagram-v2
+    state "Draft" as s0
+    state "Review" as s1
+    state "Published" as s2
+    state "Archived" as s3
+    state "*" as s4
+    [*] --> s0
+    s0 --> s1 : submit
+    s1 --> s2
+    s4 --> s3
+`
+	if got := newExportMachine().ToMermaid(); got != want {
+		t.Fatalf("ToMermaid =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportsAreDeterministic(t *testing.T) {
+	sm := newExportMachine()
+	for _, s := range []string{"E", "D", "C", "B", "A"} {
+		sm.AddSimpleTransition("Review", s)
+	}
+
+	dot, mermaid, states := sm.ToDOT(), sm.ToMermaid(), sm.States()
+	for i := 0; i < 20; i++ {
+		if sm.ToDOT() != dot || sm.ToMermaid() != mermaid {
+			t.Fatal("export changed between calls")
+		}
+		for j, s := range sm.States() {
+			if s != states[j] {
+				t.Fatal("States changed order between calls")
+			}
+		}
+	}
+}
+
+func TestToGraphJSON(t *testing.T) {
+	sm := newExportMachine()
+	sm.Fire("submit")
+
+	got, err := sm.ToGraphJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"initial":"Draft","current":"Review","states":["Draft","Review","Published","Archived"],"edges":[` +
+		`{"from":"Draft","to":"Review","guarded":false},` +
+		`{"from":"Review","to":"Published","guarded":true},` +
+		`{"from":"*","to":"Archived","guarded":false}]}`
+	if string(got) != want {
+		t.Fatalf("ToGraphJSON =\n%s\nwant\n%s", got, want)
+	}
+}
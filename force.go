@@ -0,0 +1,40 @@
+package statemachine
+
+import "fmt"
+
+// let ForceState move the machine into states that don't appear anywhere in its definition
+func WithAllowUnknown() Option {
+	return func(sm *StateMachine) {
+		sm.allowUnknown = true
+	}
+}
+
+// set the current state directly, e.g. when rehydrating a machine from a database row. no guards are
+// checked, no edge to the state needs to exist, and no actions, hooks or timeouts are run - like
+// Restore, only the current state changes. the move is recorded in the history with Forced set so it
+// can be told apart from a real transition. ErrUnknownState is returned for a state the definition
+// doesn't mention, unless the machine was created with WithAllowUnknown
+func (sm *StateMachine) ForceState(s State) error {
+	if !sm.allowUnknown && !sm.knows(s) {
+		return fmt.Errorf("%w: %s", ErrUnknownState, stateName(s))
+	}
+
+	entry := HistoryEntry{To: s, Time: sm.clock.Now(), Forced: true}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	entry.From = sm.State
+	sm.State = s
+	sm.history = append(sm.history, entry)
+	return nil
+}
+
+// report whether a state appears anywhere in the machine's definition
+func (sm *StateMachine) knows(s State) bool {
+	for _, known := range sm.allStates() {
+		if sm.sameState(known, s) {
+			return true
+		}
+	}
+	return false
+}
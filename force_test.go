@@ -0,0 +1,64 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestForceState(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []statemachine.Option
+		to      statemachine.State
+		wantErr error
+		want    statemachine.State
+	}{
+		{name: "known state", to: "Delivered", want: "Delivered"},
+		{name: "unknown state", to: "Lost", wantErr: statemachine.ErrUnknownState, want: "Created"},
+		{name: "unknown state allowed", opts: []statemachine.Option{statemachine.WithAllowUnknown()}, to: "Lost", want: "Lost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran bool
+			sm := statemachine.NewStateMachine("Created", tt.opts...)
+			sm.AddTransitions("Created", "Paid")
+			sm.AddTransitions("Paid", "Delivered")
+			sm.AddTransition("Created", "Delivered", func() bool { ran = true; return false }, nil)
+			sm.SetEntryAction("Delivered", func() error { ran = true; return nil })
+
+			if err := sm.ForceState(tt.to); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ForceState = %v, want %v", err, tt.wantErr)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+			if ran {
+				t.Fatal("ForceState ran a guard or action")
+			}
+		})
+	}
+}
+
+func TestForceStateRecordsHistory(t *testing.T) {
+	sm := newOrderMachine()
+	if err := sm.Transition("Paid"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.ForceState("Delivered"); err != nil {
+		t.Fatal(err)
+	}
+
+	history := sm.History()
+	if len(history) != 2 {
+		t.Fatalf("history has %d entries, want 2", len(history))
+	}
+	if history[0].Forced {
+		t.Fatal("transition recorded as forced")
+	}
+	if got := history[1]; got.From != "Paid" || got.To != "Delivered" || !got.Forced {
+		t.Fatalf("forced entry = %+v, want Paid -> Delivered, forced", got)
+	}
+}
@@ -0,0 +1,38 @@
+package statemachine
+
+import "reflect"
+
+// WithGuardCaching makes AvailableTransitions (and IsTerminal and Snapshot, which build on it) evaluate
+// each plain guard at most once per call, reusing the result for every other transition that shares
+// it. this helps when many transitions out of a state share one expensive guard. guards are told apart
+// by their function pointer, so closures built from the same function literal count as the same guard
+// even when they capture different values - only enable this when that can't happen. guards that
+// receive the target state, the payload, or the history are never cached
+func WithGuardCaching() Option {
+	return func(sm *StateMachine) {
+		sm.cacheGuards = true
+	}
+}
+
+// the results of the guards evaluated so far, keyed by function pointer
+type guardCache map[uintptr]bool
+
+// evaluate a guard, or reuse its earlier result. a nil cache evaluates every time
+func (c guardCache) passes(guard Guard) bool {
+	if c == nil {
+		return guard()
+	}
+	return c.lookup(guard)
+}
+
+// evaluate a guard through the cache. kept apart from passes so the reflection needed to tell guards
+// apart stays off the uncached path
+func (c guardCache) lookup(guard Guard) bool {
+	key := reflect.ValueOf(guard).Pointer()
+	if ok, seen := c[key]; seen {
+		return ok
+	}
+	ok := guard()
+	c[key] = ok
+	return ok
+}
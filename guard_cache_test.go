@@ -0,0 +1,53 @@
+package statemachine_test
+
+import (
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestGuardCaching(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []statemachine.Option
+		wantCalls int
+	}{
+		{name: "without caching", wantCalls: 2},
+		{name: "with caching", opts: []statemachine.Option{statemachine.WithGuardCaching()}, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			ready := func() bool {
+				calls++
+				return true
+			}
+
+			sm := statemachine.NewStateMachine("Idle", tt.opts...)
+			sm.AddTransition("Idle", "Running", ready, nil)
+			sm.AddTransition("Idle", "Paused", ready, nil)
+
+			if got := sm.AvailableTransitions(); len(got) != 2 {
+				t.Fatalf("AvailableTransitions = %v, want both targets", got)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("shared guard ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGuardCachingIsPerCall(t *testing.T) {
+	open := true
+	sm := statemachine.NewStateMachine("Idle", statemachine.WithGuardCaching())
+	sm.AddTransition("Idle", "Running", func() bool { return open }, nil)
+
+	if got := sm.AvailableTransitions(); len(got) != 1 {
+		t.Fatalf("AvailableTransitions = %v, want [Running]", got)
+	}
+	open = false
+	if got := sm.AvailableTransitions(); len(got) != 0 {
+		t.Fatalf("AvailableTransitions = %v after the guard closed, want none", got)
+	}
+}
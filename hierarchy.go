@@ -0,0 +1,74 @@
+package statemachine
+
+// declare `child` as a substate of `parent`. while the machine is in the child state (or any of its
+// own substates), transitions defined on the parent apply as well, after the child's own transitions.
+// leaving a substate for a state outside its parent runs the substate's exit action and then the
+// parent's; entering a substate from outside its parent runs the parent's entry action first.
+// a state has a single parent, so declaring it again moves it under the new parent
+func (sm *StateMachine) AddSubstate(parent, child State) {
+	sm.remember(parent, child)
+	sm.parents[sm.key(child)] = parent
+}
+
+// the parents of a state, innermost first. a state that was (mistakenly) made its own ancestor
+// stops the walk rather than looping forever
+func (sm *StateMachine) ancestors(s State) []State {
+	var chain []State
+	seen := map[State]bool{sm.key(s): true}
+	for {
+		parent, ok := sm.parents[sm.key(s)]
+		if !ok || seen[sm.key(parent)] {
+			return chain
+		}
+		seen[sm.key(parent)] = true
+		chain = append(chain, parent)
+		s = parent
+	}
+}
+
+// report whether `ancestor` is `s` itself or one of its parents
+func (sm *StateMachine) isAncestorOrSelf(ancestor, s State) bool {
+	if sm.sameState(ancestor, s) {
+		return true
+	}
+	for _, parent := range sm.ancestors(s) {
+		if sm.sameState(parent, ancestor) {
+			return true
+		}
+	}
+	return false
+}
+
+// the states whose exit actions run when moving from one state to another, innermost first: the
+// state being left, followed by each of its parents that doesn't also contain the target
+func (sm *StateMachine) exitChain(from, to State) []State {
+	if sm.sameState(from, to) {
+		return []State{from}
+	}
+
+	var chain []State
+	for _, s := range append([]State{from}, sm.ancestors(from)...) {
+		if sm.isAncestorOrSelf(s, to) {
+			break
+		}
+		chain = append(chain, s)
+	}
+	return chain
+}
+
+// the states whose entry actions run when moving from one state to another, outermost first: each
+// parent of the target that doesn't already contain the state being left, followed by the target
+func (sm *StateMachine) entryChain(from, to State) []State {
+	if sm.sameState(from, to) {
+		return []State{to}
+	}
+
+	var chain []State
+	for _, s := range append([]State{to}, sm.ancestors(to)...) {
+		if sm.isAncestorOrSelf(s, from) {
+			break
+		}
+		chain = append([]State{s}, chain...)
+	}
+	return chain
+}
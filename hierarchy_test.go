@@ -0,0 +1,71 @@
+package statemachine_test
+
+import (
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+// a media player whose Playing and Paused states are substates of Active
+func newPlayer(log *[]string) *statemachine.StateMachine {
+	sm := statemachine.NewStateMachine("Stopped")
+	sm.AddSubstate("Active", "Playing")
+	sm.AddSubstate("Active", "Paused")
+	sm.AddTransitions("Stopped", "Playing")
+	sm.AddTransitions("Playing", "Paused")
+	sm.AddTransitions("Active", "Stopped")
+	for _, s := range []statemachine.State{"Stopped", "Active", "Playing", "Paused"} {
+		name := s.(string)
+		sm.SetEntryAction(s, logged(log, "enter "+name))
+		sm.SetExitAction(s, logged(log, "exit "+name))
+	}
+	return sm
+}
+
+func TestSubstates(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    []statemachine.State
+		to      statemachine.State
+		wantLog []string
+	}{
+		{
+			name:    "entering a substate enters its parent first",
+			to:      "Playing",
+			wantLog: []string{"exit Stopped", "enter Active", "enter Playing"},
+		},
+		{
+			name:    "moving between siblings stays in the parent",
+			path:    []statemachine.State{"Playing"},
+			to:      "Paused",
+			wantLog: []string{"exit Playing", "enter Paused"},
+		},
+		{
+			name:    "parent transition applies to substates",
+			path:    []statemachine.State{"Playing", "Paused"},
+			to:      "Stopped",
+			wantLog: []string{"exit Paused", "exit Active", "enter Stopped"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			sm := newPlayer(&log)
+			for _, s := range tt.path {
+				if err := sm.Transition(s); err != nil {
+					t.Fatal(err)
+				}
+			}
+			log = nil
+
+			if err := sm.Transition(tt.to); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(log, tt.wantLog) {
+				t.Fatalf("ran %v, want %v", log, tt.wantLog)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package statemachine
+
+import "time"
+
+// HistoryEntry records a single successful transition, or a move made with ForceState
+type HistoryEntry struct {
+	From   State
+	To     State
+	Time   time.Time
+	Forced bool // whether the state was set with ForceState rather than reached through a transition
+}
+
+// GuardHist is a guard that receives a copy of the machine's transition history, oldest first, for
+// rules that depend on the path taken, e.g. only allowing a refund for an order that was shipped
+type GuardHist func(history []HistoryEntry) bool
+
+// add a transition guarded by the machine's history. the guard is handed a fresh copy of the history
+// every time it's evaluated, so it's free to hold on to or modify what it receives
+func (sm *StateMachine) AddTransitionGuardHist(from, to State, guard GuardHist, action Action) {
+	sm.addTransition(Transition{
+		From:      from,
+		To:        to,
+		GuardHist: guard,
+		Action:    action,
+	})
+}
+
+// return a copy of every successful transition the machine has made, oldest first. the history
+// grows for the lifetime of the machine and is only cleared by `ResetAll`, not `Reset`
+func (sm *StateMachine) History() []HistoryEntry {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.copyHistory()
+}
+
+// copy the history without touching the lock, for callers that already hold it
+func (sm *StateMachine) copyHistory() []HistoryEntry {
+	return append([]HistoryEntry(nil), sm.history...)
+}
+
+func (sm *StateMachine) recordHistory(from, to State) {
+	entry := HistoryEntry{From: from, To: to, Time: sm.clock.Now()}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.history = append(sm.history, entry)
+}
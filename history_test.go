@@ -0,0 +1,70 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestHistory(t *testing.T) {
+	sm := newOrderMachine()
+	for _, to := range []statemachine.State{"Paid", "Shipped"} {
+		if err := sm.Transition(to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sm.Transition("Created") // rejected, so not recorded
+
+	history := sm.History()
+	if len(history) != 2 {
+		t.Fatalf("history has %d entries, want 2", len(history))
+	}
+	if history[0].From != "Created" || history[0].To != "Paid" || history[1].From != "Paid" || history[1].To != "Shipped" {
+		t.Fatalf("history = %+v", history)
+	}
+
+	history[0].To = "Cancelled"
+	if sm.History()[0].To != "Paid" {
+		t.Fatal("changing the returned history changed the machine's")
+	}
+}
+
+func TestGuardHist(t *testing.T) {
+	// the order may only be shipped if it was paid for, not just reached Ready some other way
+	wasPaid := func(history []statemachine.HistoryEntry) bool {
+		for _, h := range history {
+			if h.To == "Paid" {
+				return true
+			}
+		}
+		return false
+	}
+
+	tests := []struct {
+		name    string
+		path    []statemachine.State
+		wantErr error
+	}{
+		{name: "paid first", path: []statemachine.State{"Paid", "Ready"}},
+		{name: "never paid", path: []statemachine.State{"Ready"}, wantErr: statemachine.ErrGuardFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Created")
+			sm.AddTransitions("Created", "Paid", "Ready")
+			sm.AddTransitions("Paid", "Ready")
+			sm.AddTransitionGuardHist("Ready", "Shipped", wasPaid, nil)
+
+			for _, to := range tt.path {
+				if err := sm.Transition(to); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := sm.Transition("Shipped"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition(Shipped) = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package statemachine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// the body served by GET /state: the machine's description plus the transitions currently allowed
+type stateResponse struct {
+	Description
+	Available []string `json:"available"`
+}
+
+// the body accepted by POST /transition
+type transitionRequest struct {
+	To string `json:"to"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// expose the machine as a minimal REST service, handy for prototyping a workflow:
+//
+//	GET  /state       the current state, the machine's description, and the allowed transitions
+//	POST /transition  a body of {"to": "<state>"} performs the transition and returns the new state
+//
+// states are matched by their formatted value, so the target in the request must be written the
+// same way it appears in the GET response. a rejected transition is reported with 409 Conflict
+func (sm *StateMachine) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+			return
+		}
+		writeJSON(w, http.StatusOK, sm.stateResponse())
+	})
+	mux.HandleFunc("/transition", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+			return
+		}
+
+		var req transitionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid request body: %v", err)})
+			return
+		}
+
+		to, ok := sm.lookupState(req.To)
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("unknown state %q", req.To)})
+			return
+		}
+
+		if err := sm.Transition(to); err != nil {
+			writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, sm.stateResponse())
+	})
+	return mux
+}
+
+func (sm *StateMachine) stateResponse() stateResponse {
+	available := []string{}
+	for _, s := range sm.AvailableTransitions() {
+		available = append(available, stateName(s))
+	}
+	return stateResponse{Description: sm.Describe(), Available: available}
+}
+
+// find the known state whose formatted value matches the given name
+func (sm *StateMachine) lookupState(name string) (State, bool) {
+	for _, s := range sm.allStates() {
+		if stateName(s) == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
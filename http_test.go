@@ -0,0 +1,93 @@
+package statemachine_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func newDocumentHandler() (*statemachine.StateMachine, http.Handler) {
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddSimpleTransition("Draft", "Review").AddSimpleTransition("Draft", "Rejected").AddSimpleTransition("Review", "Published")
+	return sm, sm.HTTPHandler()
+}
+
+func postTransition(h http.Handler, to string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/transition", strings.NewReader(`{"to":"`+to+`"}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHTTPHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		to       string
+		wantCode int
+		want     statemachine.State
+	}{
+		{name: "valid target", to: "Review", wantCode: http.StatusOK, want: "Review"},
+		{name: "undefined edge", to: "Published", wantCode: http.StatusConflict, want: "Draft"},
+		{name: "unknown state", to: "Nowhere", wantCode: http.StatusBadRequest, want: "Draft"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, h := newDocumentHandler()
+			rec := postTransition(h, tt.to)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPHandlerGetState(t *testing.T) {
+	_, h := newDocumentHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/state", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	for _, want := range []string{`"current":"Draft"`, `"available":["Review","Rejected"]`} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("body %s doesn't contain %s", rec.Body, want)
+		}
+	}
+}
+
+func TestHTTPHandlerConcurrentRequests(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		_, h := newDocumentHandler()
+
+		var wg sync.WaitGroup
+		codes := make(chan int, 2)
+		for _, to := range []string{"Review", "Rejected"} {
+			wg.Add(1)
+			go func(to string) {
+				defer wg.Done()
+				codes <- postTransition(h, to).Code
+			}(to)
+		}
+		wg.Wait()
+		close(codes)
+
+		ok := 0
+		for code := range codes {
+			if code == http.StatusOK {
+				ok++
+			}
+		}
+		if ok != 1 {
+			t.Fatalf("%d concurrent transitions out of Draft succeeded, want 1", ok)
+		}
+	}
+}
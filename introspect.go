@@ -0,0 +1,46 @@
+package statemachine
+
+// return a copy of the full transition table, keyed by source state, for tooling that wants to walk
+// the whole definition. each state's transitions are listed in registration order, and changes to
+// the returned map or its transitions have no effect on the machine
+func (sm *StateMachine) TransitionMap() map[State][]Transition {
+	table := make(map[State][]Transition, len(sm.Transitions))
+	for from, transitions := range sm.Transitions {
+		table[from] = copyTransitions(transitions)
+	}
+	return table
+}
+
+// return a copy of the transition table reduced to its shape: each source state mapped to its targets,
+// in registration order. unlike reading `Transitions` directly, this is safe to hold on to and modify,
+// since nothing in it is shared with the machine
+func (sm *StateMachine) TransitionsCopy() map[State][]State {
+	table := make(map[State][]State, len(sm.Transitions))
+	for from, transitions := range sm.Transitions {
+		targets := make([]State, len(transitions))
+		for i, t := range transitions {
+			targets[i] = t.To
+		}
+		table[from] = targets
+	}
+	return table
+}
+
+// list every state the machine knows about: the initial state followed by every state used as the
+// source or target of a transition, used as a substate or parent, or declared with RegisterState,
+// each listed once in the order it was first registered
+func (sm *StateMachine) States() []State {
+	return sm.allStates()
+}
+
+// find the transition that would be used to move from the current state to `to`, without checking
+// its guards. the transition is a copy, so changing it has no effect on the machine. false is
+// returned when no transition to `to` is defined from the current state
+func (sm *StateMachine) FindTransition(to State) (*Transition, bool) {
+	t, r := sm.lookup(sm.current(), to)
+	if r != accepted {
+		return nil, false
+	}
+	copied := t.deepCopy()
+	return &copied, true
+}
@@ -0,0 +1,62 @@
+package statemachine_test
+
+import (
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestStates(t *testing.T) {
+	sm := statemachine.NewStateMachine("Created")
+	sm.AddTransitions("Created", "Paid", "Cancelled")
+	sm.AddSubstate("Fulfilment", "Shipped")
+	sm.RegisterState("Refunded")
+	sm.AddTransition(statemachine.AnyState, "Cancelled", nil, nil)
+
+	want := []statemachine.State{"Created", "Paid", "Cancelled", "Fulfilment", "Shipped", "Refunded"}
+	got := sm.States()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("States = %v, want %v", got, want)
+	}
+}
+
+func TestTransitionMapIsACopy(t *testing.T) {
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddGuardedTransition("Draft", "Review", func() bool { return true })
+	sm.AddTransitionWithMeta("Review", "Published", nil, nil, map[string]string{"role": "editor"})
+
+	table := sm.TransitionMap()
+	table["Draft"][0].To = "Published"
+	table["Draft"][0].Guards[0] = func() bool { return false }
+	table["Review"][0].Metadata["role"] = "anyone"
+	delete(table, "Review")
+
+	shape := sm.TransitionsCopy()
+	shape["Draft"][0] = "Nowhere"
+
+	if err := sm.Transition("Review"); err != nil {
+		t.Fatalf("changing the copies changed the machine: %v", err)
+	}
+	if got := sm.Transitions["Review"][0].Metadata["role"]; got != "editor" {
+		t.Fatalf("metadata = %q, want editor", got)
+	}
+}
+
+func TestFindTransition(t *testing.T) {
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddTransitionWithMeta("Draft", "Review", func() bool { return false }, nil, map[string]string{"role": "author"})
+
+	found, ok := sm.FindTransition("Review")
+	if !ok || found.To != "Review" || found.Metadata["role"] != "author" {
+		t.Fatalf("FindTransition = %+v, %v, want the guarded transition to Review", found, ok)
+	}
+	found.Metadata["role"] = "anyone"
+	if again, _ := sm.FindTransition("Review"); again.Metadata["role"] != "author" {
+		t.Fatal("changing the found transition changed the machine")
+	}
+
+	if _, ok := sm.FindTransition("Published"); ok {
+		t.Fatal("FindTransition found a transition that isn't defined")
+	}
+}
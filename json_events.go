@@ -0,0 +1,88 @@
+package statemachine
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// the outcomes written by the JSON event writer
+const (
+	outcomeSuccess       = "success"
+	outcomeGuardRejected = "guard_rejected"
+	outcomeActionFailed  = "action_failed"
+	outcomeInvalid       = "invalid"
+)
+
+// a single line written by the JSON event writer
+type jsonEvent struct {
+	Time    time.Time `json:"time"`
+	From    string    `json:"from"`
+	To      string    `json:"to,omitempty"` // left out when an event matched no transition
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// writes every transition attempt to `w` as a line of JSON
+type jsonEventLogger struct {
+	mu sync.Mutex // keeps lines from concurrent transitions from interleaving
+	w  io.Writer
+	sm *StateMachine // the machine whose clock timestamps each line
+}
+
+// write every transition attempt to `w` as one line of JSON, for feeding into a log pipeline:
+//
+//	{"time":"...","from":"Draft","to":"Review","outcome":"success"}
+//
+// the outcome is "success", "guard_rejected" when a guard or entry guard wasn't satisfied, "invalid"
+// when no such transition is defined or it isn't allowed, and "action_failed" for every other failure
+// (an action, hook or timeout). failures also carry the error message. the lines are written
+// alongside the machine's Logger rather than replacing it, and passing nil stops writing them. errors
+// writing to `w` are ignored
+func (sm *StateMachine) SetJSONEventWriter(w io.Writer) {
+	if w == nil {
+		sm.events = nil
+		return
+	}
+	sm.events = &jsonEventLogger{w: w, sm: sm}
+}
+
+func (l *jsonEventLogger) Transitioned(from, to State) {
+	l.write(from, to, jsonEvent{Outcome: outcomeSuccess})
+}
+
+func (l *jsonEventLogger) TransitionRejected(from, to State, err error) {
+	l.write(from, to, jsonEvent{Outcome: outcome(err), Error: err.Error()})
+}
+
+func (l *jsonEventLogger) write(from, to State, event jsonEvent) {
+	event.Time = l.sm.clock.Now()
+	event.From = stateName(from)
+	if to != nil {
+		event.To = stateName(to)
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(append(line, '\n'))
+}
+
+// classify why a transition failed
+func outcome(err error) string {
+	switch {
+	case errors.Is(err, ErrGuardFailed):
+		return outcomeGuardRejected
+	case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrSelfTransition), errors.Is(err, ErrUnknownState),
+		errors.Is(err, ErrNoTransitionForEvent), errors.Is(err, ErrUnknownTransitionName), errors.Is(err, ErrNothingToUndo),
+		errors.Is(err, ErrNoWeightedTransition):
+		return outcomeInvalid
+	default:
+		return outcomeActionFailed
+	}
+}
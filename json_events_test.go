@@ -0,0 +1,94 @@
+package statemachine_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	statemachine "github.com/jwald3/lollipop"
+	"github.com/jwald3/lollipop/statemachinetest"
+)
+
+func TestJSONEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	sm := statemachine.NewStateMachine("Draft")
+	sm.SetClock(statemachinetest.NewFakeClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	sm.AddSimpleTransition("Draft", "Review")
+	sm.AddTransition("Review", "Published", func() bool { return false }, nil)
+	sm.AddSimpleTransition("Review", "Rejected")
+	sm.SetEntryAction("Rejected", func() error { return errors.New("mailer down") })
+	sm.SetJSONEventWriter(&buf)
+
+	sm.Transition("Review")
+	sm.Transition("Published")
+	sm.Transition("Rejected")
+	sm.Transition("Archived")
+	sm.Fire("publish")
+
+	want := []map[string]string{
+		{"from": "Draft", "to": "Review", "outcome": "success"},
+		{"from": "Review", "to": "Published", "outcome": "guard_rejected"},
+		{"from": "Review", "to": "Rejected", "outcome": "action_failed"},
+		{"from": "Review", "to": "Archived", "outcome": "invalid"},
+		{"from": "Review", "outcome": "invalid"},
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("wrote %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		var got map[string]string
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d isn't JSON: %v", i, err)
+		}
+		if got["time"] != "2024-01-02T03:04:05Z" {
+			t.Errorf("line %d time = %q, want the machine's clock", i, got["time"])
+		}
+		if (got["outcome"] == "success") != (got["error"] == "") {
+			t.Errorf("line %d: error %q doesn't match outcome %q", i, got["error"], got["outcome"])
+		}
+		delete(got, "time")
+		delete(got, "error")
+		for k, v := range want[i] {
+			if got[k] != v {
+				t.Errorf("line %d = %v, want %v", i, got, want[i])
+				break
+			}
+		}
+		if _, ok := got["to"]; ok && want[i]["to"] == "" {
+			t.Errorf("line %d has a target for an unmatched event: %v", i, got)
+		}
+	}
+}
+
+func TestJSONEventWriterKeepsLogger(t *testing.T) {
+	var events, logs bytes.Buffer
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddSimpleTransition("Off", "On")
+	sm.SetLogger(statemachine.NewStdLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	sm.SetJSONEventWriter(&events)
+
+	sm.Transition("On")
+	sm.Transition("Broken")
+
+	if n := strings.Count(events.String(), "\n"); n != 2 {
+		t.Errorf("wrote %d JSON lines, want 2:\n%s", n, events.String())
+	}
+	if n := strings.Count(logs.String(), "\n"); n != 2 {
+		t.Errorf("logged %d lines, want 2:\n%s", n, logs.String())
+	}
+
+	// turning the writer off leaves the logger in place
+	sm.SetJSONEventWriter(nil)
+	sm.Transition("Broken")
+	if n := strings.Count(events.String(), "\n"); n != 2 {
+		t.Errorf("wrote %d JSON lines after the writer was removed, want 2", n)
+	}
+	if n := strings.Count(logs.String(), "\n"); n != 3 {
+		t.Errorf("logged %d lines after the writer was removed, want 3", n)
+	}
+}
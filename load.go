@@ -0,0 +1,60 @@
+package statemachine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Definition is the serialized form of a machine's topology, as read by LoadFromJSON:
+//
+//	{
+//		"initial": "Draft",
+//		"transitions": [
+//			{"from": "Draft", "to": "Review"},
+//			{"from": "Review", "to": "Approved"}
+//		]
+//	}
+type Definition struct {
+	Initial     string                 `json:"initial"`
+	Transitions []DefinitionTransition `json:"transitions"`
+}
+
+// DefinitionTransition is a single edge in a Definition
+type DefinitionTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// build a state machine from a JSON definition, so that workflows can be described in configuration
+// rather than code. states are plain strings. guards and actions can't be expressed in JSON, so only
+// the topology is loaded - attach actions to the returned machine by state name afterwards
+func LoadFromJSON(r io.Reader) (*StateMachine, error) {
+	var def Definition
+	if err := json.NewDecoder(r).Decode(&def); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDefinition, err)
+	}
+
+	if def.Initial == "" {
+		return nil, fmt.Errorf("%w: no initial state", ErrInvalidDefinition)
+	}
+
+	found := false
+	for _, t := range def.Transitions {
+		if t.From == "" || t.To == "" {
+			return nil, fmt.Errorf("%w: transition from %q to %q is missing a state", ErrInvalidDefinition, t.From, t.To)
+		}
+		if t.From == def.Initial || t.To == def.Initial {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: initial state %q does not appear in any transition", ErrInvalidDefinition, def.Initial)
+	}
+
+	sm := NewStateMachine(def.Initial)
+	for _, t := range def.Transitions {
+		sm.AddSimpleTransition(t.From, t.To)
+	}
+	return sm, nil
+}
@@ -0,0 +1,83 @@
+package statemachine
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger receives a trace of every transition the machine attempts. Rejections cover every way a
+// transition can fail, from an undefined edge or failing guard to an action returning an error.
+// When an event can't be matched to any transition, `to` is nil.
+type Logger interface {
+	Transitioned(from, to State)
+	TransitionRejected(from, to State, err error)
+}
+
+// the default logger, which discards everything
+type noopLogger struct{}
+
+func (noopLogger) Transitioned(from, to State)                  {}
+func (noopLogger) TransitionRejected(from, to State, err error) {}
+
+// set the logger used to trace transitions. passing nil restores the default, which logs nothing
+func (sm *StateMachine) SetLogger(logger Logger) {
+	if logger == nil {
+		logger = noopLogger{}
+	}
+	sm.logger = logger
+}
+
+// report whether anyone is listening for transition outcomes, so rejections can skip building an
+// error when nobody would see it
+func (sm *StateMachine) listening() bool {
+	_, quietLogger := sm.logger.(noopLogger)
+	_, quietRecorder := sm.recorder.(noopRecorder)
+	return !quietLogger || !quietRecorder || sm.events != nil
+}
+
+// pass the outcome of a transition attempt along to whoever is listening, returning the error unchanged
+func (sm *StateMachine) report(from, to State, err error) error {
+	if err != nil {
+		sm.logger.TransitionRejected(from, to, err)
+		if sm.events != nil {
+			sm.events.TransitionRejected(from, to, err)
+		}
+		sm.recorder.IncRejection(from, to)
+		sm.drainQueue()
+		return err
+	}
+
+	sm.logger.Transitioned(from, to)
+	if sm.events != nil {
+		sm.events.Transitioned(from, to)
+	}
+	sm.recorder.IncTransition(from, to)
+	sm.publish(from, to)
+	sm.runAfterHooks(from, to)
+	sm.drainQueue()
+	return nil
+}
+
+// StdLogger is a Logger that writes structured records through log/slog. Successful transitions are
+// logged at info level and rejections at warn level.
+type StdLogger struct {
+	logger *slog.Logger
+}
+
+// create a logger writing to the given slog logger, or to slog's default logger if it is nil
+func NewStdLogger(logger *slog.Logger) *StdLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &StdLogger{logger: logger}
+}
+
+func (l *StdLogger) Transitioned(from, to State) {
+	l.logger.LogAttrs(context.Background(), slog.LevelInfo, "state transition",
+		slog.Any("from", from), slog.Any("to", to))
+}
+
+func (l *StdLogger) TransitionRejected(from, to State, err error) {
+	l.logger.LogAttrs(context.Background(), slog.LevelWarn, "state transition rejected",
+		slog.Any("from", from), slog.Any("to", to), slog.Any("error", err))
+}
@@ -0,0 +1,31 @@
+package statemachine_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddSimpleTransition("Off", "On")
+	sm.SetLogger(statemachine.NewStdLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	sm.Transition("On")
+	sm.Transition("Broken")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	if !containsAll(lines[0], "level=INFO", `msg="state transition"`, "from=Off", "to=On") {
+		t.Errorf("success logged as %q", lines[0])
+	}
+	if !containsAll(lines[1], "level=WARN", `msg="state transition rejected"`, "from=On", "to=Broken", "error=") {
+		t.Errorf("rejection logged as %q", lines[1])
+	}
+}
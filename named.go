@@ -0,0 +1,64 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownTransitionName is returned by FireNamed when no transition has the given name
+var ErrUnknownTransitionName = errors.New("no transition with that name")
+
+// add a transition with a name, e.g. "approve", so it can be triggered with FireNamed and is easy to
+// pick out in error messages
+func (sm *StateMachine) AddNamedTransition(name string, from, to State, guard Guard) {
+	sm.addTransition(Transition{
+		From:  from,
+		To:    to,
+		Guard: guard,
+		Name:  name,
+	})
+}
+
+// perform the transition with the given name, but only if it leaves the state the machine is in (or
+// one of its parents). errors name the transition, and wrap ErrUnknownTransitionName when no
+// transition anywhere has that name
+func (sm *StateMachine) FireNamed(name string) error {
+	sm.beginTransition()
+	from := sm.current()
+	to, err := sm.fireNamed(from, name)
+	sm.endTransition()
+
+	err = sm.report(from, to, err)
+	if err != nil && !errors.Is(err, ErrUnknownTransitionName) {
+		return fmt.Errorf("transition %q: %w", name, err)
+	}
+	return err
+}
+
+// the body of FireNamed, returning the target the attempt should be reported against
+func (sm *StateMachine) fireNamed(from State, name string) (State, error) {
+	for _, t := range sm.candidates(from) {
+		if t.Name == name {
+			return t.To, sm.perform(t, execution{})
+		}
+	}
+
+	for _, t := range sm.orderedTransitions() {
+		if t.Name == name {
+			return t.To, &TransitionError{From: from, To: t.To, Reason: fmt.Sprintf("transition %q leaves from %s", name, stateName(t.From))}
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownTransitionName, name)
+}
+
+// list the names of the transitions leaving the current state, in the order they'd be considered.
+// unnamed transitions are left out
+func (sm *StateMachine) TransitionNames() []string {
+	var names []string
+	for _, t := range sm.candidates(sm.current()) {
+		if t.Name != "" {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,51 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func newNamedMachine() *statemachine.StateMachine {
+	sm := statemachine.NewStateMachine("Open")
+	sm.AddNamedTransition("resolve", "Open", "Resolved", nil)
+	sm.AddNamedTransition("close", "Open", "Closed", func() bool { return false })
+	sm.AddNamedTransition("reopen", "Resolved", "Open", nil)
+	sm.AddSimpleTransition("Open", "Spam")
+	return sm
+}
+
+func TestFireNamed(t *testing.T) {
+	tests := []struct {
+		name    string
+		fire    string
+		wantErr error
+		want    statemachine.State
+	}{
+		{name: "named transition", fire: "resolve", want: "Resolved"},
+		{name: "guard fails", fire: "close", wantErr: statemachine.ErrGuardFailed, want: "Open"},
+		{name: "wrong source state", fire: "reopen", wantErr: statemachine.ErrInvalidTransition, want: "Open"},
+		{name: "unknown name", fire: "escalate", wantErr: statemachine.ErrUnknownTransitionName, want: "Open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newNamedMachine()
+			if err := sm.FireNamed(tt.fire); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FireNamed(%q) = %v, want %v", tt.fire, err, tt.wantErr)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionNames(t *testing.T) {
+	sm := newNamedMachine()
+	if got, want := sm.TransitionNames(), []string{"resolve", "close"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TransitionNames = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package statemachine
+
+import (
+	"fmt"
+	"sort"
+)
+
+// note that states have been registered, remembering the order in which each was first seen. Go
+// maps have no order, so this is what keeps listings and exports built from the transition table
+// the same from one run to the next
+func (sm *StateMachine) remember(states ...State) {
+	for _, s := range states {
+		sm.mustBeComparable(s)
+		if sm.equal != nil {
+			if _, ok := sm.knownKey(s); ok {
+				continue
+			}
+		}
+
+		k := s
+		if !hashable(s) {
+			k = stateKey(len(sm.order))
+		}
+		if !sm.remembered[k] {
+			sm.remembered[k] = true
+			sm.order = append(sm.order, s)
+			sm.keys = append(sm.keys, k)
+		}
+	}
+}
+
+// list the states in a set of keys (see `key`) in the order they were first registered. states the
+// machine never saw registered (e.g. written straight into `Transitions`) come last, sorted by name
+// so the result is still stable
+func (sm *StateMachine) inOrder(set map[State]bool) []State {
+	states := make([]State, 0, len(set))
+	for i, s := range sm.order {
+		if set[sm.keys[i]] {
+			states = append(states, s)
+		}
+	}
+	if len(states) == len(set) {
+		return states
+	}
+
+	var rest []State
+	for s := range set {
+		if !sm.remembered[s] {
+			rest = append(rest, sm.stateOf(s))
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		if a, b := stateName(rest[i]), stateName(rest[j]); a != b {
+			return a < b
+		}
+		return fmt.Sprintf("%T", rest[i]) < fmt.Sprintf("%T", rest[j])
+	})
+	return append(states, rest...)
+}
+
+// the states with an entry in the transition table, in registration order
+func (sm *StateMachine) sources() []State {
+	set := make(map[State]bool, len(sm.Transitions))
+	for from := range sm.Transitions {
+		set[from] = true
+	}
+	return sm.inOrder(set)
+}
@@ -0,0 +1,27 @@
+package statemachine
+
+// a transition out of a state to any target accepted by `accepts`, see AddPredicateTransition
+type predicateTransition struct {
+	accepts func(to State) bool
+	guard   Guard
+}
+
+// allow transitions from `from` to any state satisfying `pred`, e.g. every state whose name starts with
+// "Review", as long as `guard` (if any) passes. a transition registered for the exact target always
+// takes precedence, and predicates are tried in the order they were added. predicate transitions only
+// apply to `from` itself, not its substates, and since their targets aren't known up front they don't
+// appear in the transition table, exports or analysis
+func (sm *StateMachine) AddPredicateTransition(from State, pred func(to State) bool, guard Guard) {
+	k := sm.key(from)
+	sm.predicates[k] = append(sm.predicates[k], predicateTransition{accepts: pred, guard: guard})
+}
+
+// find a predicate transition from `from` accepting `to`, building the transition it stands for
+func (sm *StateMachine) matchPredicate(from, to State) (Transition, bool) {
+	for _, p := range sm.predicates[sm.key(from)] {
+		if p.accepts(to) {
+			return Transition{From: from, To: to, Guard: p.guard}, true
+		}
+	}
+	return Transition{}, false
+}
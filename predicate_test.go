@@ -0,0 +1,52 @@
+package statemachine_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestPredicateTransitions(t *testing.T) {
+	inReview := func(to statemachine.State) bool {
+		s, ok := to.(string)
+		return ok && strings.HasPrefix(s, "Review")
+	}
+
+	tests := []struct {
+		name    string
+		to      statemachine.State
+		guard   statemachine.Guard
+		wantErr error
+	}{
+		{name: "accepted target", to: "ReviewLegal"},
+		{name: "another accepted target", to: "ReviewSecurity"},
+		{name: "rejected target", to: "Published", wantErr: statemachine.ErrInvalidTransition},
+		{name: "guarded", to: "ReviewLegal", guard: func() bool { return false }, wantErr: statemachine.ErrGuardFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Draft")
+			sm.AddPredicateTransition("Draft", inReview, tt.guard)
+
+			if err := sm.Transition(tt.to); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition(%v) error = %v, want %v", tt.to, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExactTransitionBeatsPredicate(t *testing.T) {
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddTransition("Draft", "ReviewLegal", func() bool { return false }, nil)
+	sm.AddPredicateTransition("Draft", func(statemachine.State) bool { return true }, nil)
+
+	if err := sm.Transition("ReviewLegal"); !errors.Is(err, statemachine.ErrGuardFailed) {
+		t.Fatalf("Transition error = %v, want the exact transition's guard to apply", err)
+	}
+	if err := sm.Transition("ReviewSecurity"); err != nil {
+		t.Fatal(err)
+	}
+}
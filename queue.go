@@ -0,0 +1,44 @@
+package statemachine
+
+// ask for a transition to `to` without racing whatever the machine is doing right now. if no
+// transition is in progress, the queued transition runs straight away on the calling goroutine;
+// otherwise it waits its turn and runs once the machine is idle again, on the goroutine that finished
+// the transition in progress. a transition counts as in progress from the moment it starts matching
+// against the current state, so guards and actions, after-transition hooks and timeouts firing
+// can all queue transitions safely. queued transitions run one at a time in the order they were
+// queued, each from whatever state the previous one left the machine in. their outcomes aren't
+// returned - a queued transition that fails is reported to the logger and recorder like any other and
+// the queue moves on
+func (sm *StateMachine) QueueTransition(to State) {
+	sm.mu.Lock()
+	sm.queue = append(sm.queue, to)
+	sm.mu.Unlock()
+
+	sm.drainQueue()
+}
+
+// run queued transitions until the queue is empty, unless a transition is in progress or the queue is
+// already being drained, in which case whoever is responsible will get to them
+func (sm *StateMachine) drainQueue() {
+	sm.mu.Lock()
+	if sm.busy || sm.draining || len(sm.queue) == 0 {
+		sm.mu.Unlock()
+		return
+	}
+	sm.draining = true
+	sm.mu.Unlock()
+
+	for {
+		sm.mu.Lock()
+		if len(sm.queue) == 0 {
+			sm.draining = false
+			sm.mu.Unlock()
+			return
+		}
+		to := sm.queue[0]
+		sm.queue = sm.queue[1:]
+		sm.mu.Unlock()
+
+		_ = sm.Transition(to)
+	}
+}
@@ -0,0 +1,100 @@
+package statemachine_test
+
+import (
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestQueueTransition(t *testing.T) {
+	tests := []struct {
+		name  string
+		queue func(sm *statemachine.StateMachine)
+	}{
+		{
+			name: "from an entry action",
+			queue: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("A", "B")
+				sm.SetEntryAction("B", func() error {
+					sm.QueueTransition("C")
+					sm.QueueTransition("D")
+					return nil
+				})
+			},
+		},
+		{
+			name: "from a guard",
+			queue: func(sm *statemachine.StateMachine) {
+				sm.AddTransition("A", "B", func() bool {
+					sm.QueueTransition("C")
+					sm.QueueTransition("D")
+					return true
+				}, nil)
+			},
+		},
+		{
+			name: "from an after-transition hook",
+			queue: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("A", "B")
+				sm.AfterTransition(func(sm *statemachine.StateMachine, from, to statemachine.State) {
+					if to == "B" {
+						sm.QueueTransition("C")
+						sm.QueueTransition("D")
+					}
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("A")
+			sm.AddSimpleTransition("B", "C").AddSimpleTransition("C", "D")
+			tt.queue(sm)
+
+			if err := sm.Transition("B"); err != nil {
+				t.Fatal(err)
+			}
+
+			var path []statemachine.State
+			for _, h := range sm.History() {
+				path = append(path, h.To)
+			}
+			want := []statemachine.State{"B", "C", "D"}
+			if len(path) != len(want) {
+				t.Fatalf("path = %v, want %v", path, want)
+			}
+			for i := range want {
+				if path[i] != want[i] {
+					t.Fatalf("path = %v, want %v", path, want)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueTransitionRunsWhenIdle(t *testing.T) {
+	sm := statemachine.NewStateMachine("A")
+	sm.AddSimpleTransition("A", "B")
+
+	sm.QueueTransition("B")
+	if got := sm.CurrentState(); got != "B" {
+		t.Fatalf("state = %v, want B", got)
+	}
+}
+
+func TestQueueTransitionAfterQuietRejection(t *testing.T) {
+	sm := statemachine.NewStateMachine("A")
+	sm.AddSimpleTransition("A", "B")
+	sm.AddTransition("A", "C", func() bool {
+		sm.QueueTransition("B")
+		return false
+	}, nil)
+
+	if sm.TryTransition("C") {
+		t.Fatal("TryTransition succeeded past a failing guard")
+	}
+	if got := sm.CurrentState(); got != "B" {
+		t.Fatalf("state = %v, want B", got)
+	}
+}
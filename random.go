@@ -0,0 +1,63 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// ErrNoWeightedTransition is returned by StepRandom when no weighted transition can be taken from the current state
+var ErrNoWeightedTransition = errors.New("no weighted transition available")
+
+// add a transition with a weight for StepRandom, e.g. for simulating a stochastic process. weights
+// are relative to the other weighted transitions out of the same state, and a transition with a
+// weight of zero or less is never picked. transitions added any other way have no weight
+func (sm *StateMachine) AddWeightedTransition(from, to State, weight float64) {
+	sm.addTransition(Transition{
+		From:   from,
+		To:     to,
+		Weight: weight,
+	})
+}
+
+// pick one of the current state's weighted transitions at random, with a probability proportional
+// to its weight, and perform it. transitions whose guards (or the target's entry guard) don't pass
+// right now are left out of the draw. the chosen target is returned along with the transition's
+// outcome, and ErrNoWeightedTransition is returned when there's nothing to choose from
+func (sm *StateMachine) StepRandom(rng *rand.Rand) (State, error) {
+	sm.beginTransition()
+	from := sm.current()
+	to, err := sm.stepRandom(from, rng)
+	sm.endTransition()
+	return to, sm.report(from, to, err)
+}
+
+// the body of StepRandom, returning the chosen target, if any
+func (sm *StateMachine) stepRandom(from State, rng *rand.Rand) (State, error) {
+	var eligible []Transition
+	total := 0.0
+	for _, t := range sm.candidates(from) {
+		if t.Weight <= 0 || sm.selfRejected(t, from) || !t.guardPasses(from, guardInput{machine: sm}) || !sm.entryAllowed(t.To) {
+			continue
+		}
+		eligible = append(eligible, t)
+		total += t.Weight
+	}
+	if len(eligible) == 0 {
+		return nil, fmt.Errorf("%w: from %s", ErrNoWeightedTransition, stateName(from))
+	}
+
+	// walk the transitions until the running total passes the drawn point. the last one is the
+	// fallback in case rounding leaves the point just past the end
+	chosen := eligible[len(eligible)-1]
+	point := rng.Float64() * total
+	for _, t := range eligible {
+		if point < t.Weight {
+			chosen = t
+			break
+		}
+		point -= t.Weight
+	}
+
+	return chosen.To, sm.execute(chosen, execution{})
+}
@@ -0,0 +1,50 @@
+package statemachine_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestStepRandom(t *testing.T) {
+	counts := map[statemachine.State]int{}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		sm := statemachine.NewStateMachine("Idle")
+		sm.AddWeightedTransition("Idle", "Walk", 3)
+		sm.AddWeightedTransition("Idle", "Run", 1)
+		sm.AddWeightedTransition("Idle", "Sleep", 0)
+		sm.AddTransition("Idle", "Fly", func() bool { return false }, nil)
+
+		to, err := sm.StepRandom(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := sm.CurrentState(); got != to {
+			t.Fatalf("StepRandom returned %v but the machine is in %v", to, got)
+		}
+		counts[to]++
+	}
+
+	if counts["Sleep"] != 0 || counts["Fly"] != 0 {
+		t.Fatalf("zero-weight or unweighted transitions were taken: %v", counts)
+	}
+	if counts["Walk"] < 650 || counts["Walk"] > 850 {
+		t.Fatalf("Walk taken %d times out of 1000, want about 750", counts["Walk"])
+	}
+}
+
+func TestStepRandomNothingEligible(t *testing.T) {
+	sm := statemachine.NewStateMachine("Idle")
+	sm.AddWeightedTransition("Idle", "Walk", 0)
+	sm.AddTransition("Idle", "Run", func() bool { return false }, nil)
+
+	if _, err := sm.StepRandom(rand.New(rand.NewSource(1))); !errors.Is(err, statemachine.ErrNoWeightedTransition) {
+		t.Fatalf("StepRandom = %v, want ErrNoWeightedTransition", err)
+	}
+	if got := sm.CurrentState(); got != "Idle" {
+		t.Fatalf("state = %v, want Idle", got)
+	}
+}
@@ -0,0 +1,35 @@
+package statemachine
+
+import "time"
+
+// Recorder receives metrics about the machine, e.g. to feed counters and histograms in a metrics
+// system. transitions and rejections are counted for every attempt, and entry and exit actions are
+// timed each time they run. See the README for an example backed by expvar.
+type Recorder interface {
+	IncTransition(from, to State)
+	IncRejection(from, to State)
+	ObserveActionDuration(state State, d time.Duration)
+}
+
+// the default recorder, which discards everything
+type noopRecorder struct{}
+
+func (noopRecorder) IncTransition(from, to State)                       {}
+func (noopRecorder) IncRejection(from, to State)                        {}
+func (noopRecorder) ObserveActionDuration(state State, d time.Duration) {}
+
+// set the recorder used to collect metrics. passing nil restores the default, which records nothing
+func (sm *StateMachine) SetRecorder(recorder Recorder) {
+	if recorder == nil {
+		recorder = noopRecorder{}
+	}
+	sm.recorder = recorder
+}
+
+// run a state's entry or exit action, reporting how long it took to the recorder
+func (sm *StateMachine) timed(state State, action Action) error {
+	start := sm.clock.Now()
+	err := safely(action)
+	sm.recorder.ObserveActionDuration(state, sm.clock.Now().Sub(start))
+	return err
+}
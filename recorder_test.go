@@ -0,0 +1,70 @@
+package statemachine_test
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+// a Recorder keeping everything it's told, for checking against
+type memoryRecorder struct {
+	mu          sync.Mutex
+	transitions []string
+	rejections  []string
+	durations   map[statemachine.State]int
+}
+
+func (r *memoryRecorder) IncTransition(from, to statemachine.State) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transitions = append(r.transitions, fmt.Sprintf("%v->%v", from, to))
+}
+
+func (r *memoryRecorder) IncRejection(from, to statemachine.State) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rejections = append(r.rejections, fmt.Sprintf("%v->%v", from, to))
+}
+
+func (r *memoryRecorder) ObserveActionDuration(state statemachine.State, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.durations == nil {
+		r.durations = map[statemachine.State]int{}
+	}
+	r.durations[state]++
+}
+
+func TestRecorder(t *testing.T) {
+	rec := &memoryRecorder{}
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddBidirectional("Off", "On")
+	sm.SetEntryAction("On", func() error { return nil })
+	sm.SetExitAction("On", func() error { return nil })
+	sm.SetRecorder(rec)
+
+	sm.Transition("On")
+	sm.Transition("Broken")
+	sm.TryTransition("Broken")
+	sm.Transition("Off")
+
+	if want := []string{"Off->On", "On->Off"}; !reflect.DeepEqual(rec.transitions, want) {
+		t.Errorf("transitions = %v, want %v", rec.transitions, want)
+	}
+	if want := []string{"On->Broken", "On->Broken"}; !reflect.DeepEqual(rec.rejections, want) {
+		t.Errorf("rejections = %v, want %v", rec.rejections, want)
+	}
+	if want := map[statemachine.State]int{"On": 2}; !reflect.DeepEqual(rec.durations, want) {
+		t.Errorf("timed actions = %v, want %v", rec.durations, want)
+	}
+
+	sm.SetRecorder(nil)
+	sm.Transition("On")
+	if len(rec.transitions) != 2 {
+		t.Error("recorder still used after being removed")
+	}
+}
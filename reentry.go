@@ -0,0 +1,18 @@
+package statemachine
+
+// set an action for refreshing a state in place, e.g. retrying the work a state does without leaving
+// it. it runs on an explicitly allowed self-transition into the state (see AddSelfTransition) in place
+// of the state's exit, entry and post-entry actions, which are all skipped. the transition's own action
+// still runs. a failing reentry action is reported with ErrEntryActionFailed
+func (sm *StateMachine) SetReentryAction(state State, action Action) {
+	sm.reentryActions[sm.key(state)] = action
+}
+
+// the reentry action to run when moving from one state to another, which is nil unless it's a
+// self-transition into a state with a reentry action
+func (sm *StateMachine) reentryActionFor(from, to State) Action {
+	if !sm.sameState(from, to) {
+		return nil
+	}
+	return sm.reentryActions[sm.key(to)]
+}
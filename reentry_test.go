@@ -0,0 +1,79 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestSelfTransitionActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		reentry bool
+		wantLog []string
+	}{
+		{name: "exit then entry", wantLog: []string{"exit", "action", "entry", "post-entry"}},
+		{name: "reentry action instead", reentry: true, wantLog: []string{"action", "reentry"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			sm := statemachine.NewStateMachine("Polling")
+			sm.AddSelfTransition("Polling", nil, logged(&log, "action"))
+			sm.SetExitAction("Polling", logged(&log, "exit"))
+			sm.SetEntryAction("Polling", logged(&log, "entry"))
+			sm.SetPostEntryAction("Polling", logged(&log, "post-entry"))
+			if tt.reentry {
+				sm.SetReentryAction("Polling", logged(&log, "reentry"))
+			}
+
+			if err := sm.Transition("Polling"); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(log, tt.wantLog) {
+				t.Fatalf("ran %v, want %v", log, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestReentryActionFailure(t *testing.T) {
+	sm := statemachine.NewStateMachine("Polling")
+	sm.AddSelfTransition("Polling", nil, nil)
+	sm.SetReentryAction("Polling", failing("upstream down"))
+
+	if err := sm.Transition("Polling"); !errors.Is(err, statemachine.ErrEntryActionFailed) {
+		t.Fatalf("Transition error = %v, want ErrEntryActionFailed", err)
+	}
+	if n := len(sm.History()); n != 0 {
+		t.Fatalf("a failed reentry was recorded in the history")
+	}
+}
+
+func TestVisitCounts(t *testing.T) {
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddBidirectional("Off", "On")
+	for i := 0; i < 3; i++ {
+		sm.Transition("On")
+		sm.Transition("Off")
+	}
+	sm.SetEntryAction("On", failing("no bulb"))
+	sm.Transition("On")
+
+	want := map[statemachine.State]int{"Off": 4, "On": 3}
+	if got := sm.AllVisitCounts(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("AllVisitCounts = %v, want %v", got, want)
+	}
+
+	sm.Reset()
+	if got := sm.VisitCount("On"); got != 3 {
+		t.Fatalf("VisitCount after Reset = %d, want 3", got)
+	}
+	sm.ResetCounts()
+	if got := sm.AllVisitCounts(); len(got) != 0 {
+		t.Fatalf("AllVisitCounts after ResetCounts = %v, want none", got)
+	}
+}
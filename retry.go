@@ -0,0 +1,55 @@
+package statemachine
+
+import "time"
+
+// WithGuardRetry makes every transition re-evaluate a failing guard, waiting `backoff` between tries,
+// until it passes or it has been evaluated `attempts` times in total. This suits guards that depend
+// on an external condition that is expected to become true shortly. Note that `Transition` blocks
+// while it waits.
+func WithGuardRetry(attempts int, backoff time.Duration) Option {
+	return func(sm *StateMachine) {
+		sm.guardAttempts = attempts
+		sm.guardBackoff = backoff
+	}
+}
+
+// evaluate a transition's guard, retrying according to the machine's guard retry settings
+func (sm *StateMachine) checkGuard(t Transition, from State, payload any) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		ok, err := t.evaluate(from, guardInput{payload: payload, machine: sm})
+		if ok {
+			return true, nil
+		}
+		if attempt >= sm.guardAttempts {
+			return false, err
+		}
+		<-sm.clock.After(sm.guardBackoff)
+	}
+}
+
+// like `Transition`, but a failing entry action is retried up to `attempts` times in total, waiting
+// `backoff` between tries, before the transition is given up on and rolled back. this is meant for
+// entry actions that talk to a flaky service. only the entry action is retried - the exit action and
+// the transition's own action run once. if every attempt fails, the last error is returned wrapped in
+// ErrEntryActionFailed
+func (sm *StateMachine) TransitionWithRetry(to State, attempts int, backoff time.Duration) error {
+	sm.beginTransition()
+	from := sm.current()
+	t, err := sm.match(from, to)
+	if err == nil {
+		err = sm.perform(t, execution{entryAttempts: attempts, entryBackoff: backoff})
+	}
+	sm.endTransition()
+	return sm.report(from, to, err)
+}
+
+// run the entry action for entering a state from `from`, retrying it as the execution allows
+func (sm *StateMachine) retryEntryAction(from, state State, exec execution) error {
+	for attempt := 1; ; attempt++ {
+		err := sm.enter(from, state)
+		if err == nil || attempt >= exec.entryAttempts {
+			return err
+		}
+		<-sm.clock.After(exec.entryBackoff)
+	}
+}
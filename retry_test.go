@@ -0,0 +1,103 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+// an action that fails the first `failures` times it runs
+func flaky(failures int, calls *int) statemachine.Action {
+	return func() error {
+		*calls++
+		if *calls <= failures {
+			return errors.New("temporarily unavailable")
+		}
+		return nil
+	}
+}
+
+func TestTransitionWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		wantErr   error
+		wantCalls int
+		want      statemachine.State
+	}{
+		{name: "succeeds first time", failures: 0, wantCalls: 1, want: "Synced"},
+		{name: "succeeds on a retry", failures: 2, wantCalls: 3, want: "Synced"},
+		{name: "runs out of attempts", failures: 5, wantErr: statemachine.ErrEntryActionFailed, wantCalls: 3, want: "Pending"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, exits := 0, 0
+			sm := statemachine.NewStateMachine("Pending")
+			sm.AddSimpleTransition("Pending", "Synced")
+			sm.SetEntryAction("Synced", flaky(tt.failures, &calls))
+			sm.SetExitAction("Pending", func() error {
+				exits++
+				return nil
+			})
+
+			if err := sm.TransitionWithRetry("Synced", 3, time.Millisecond); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TransitionWithRetry error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls || exits != 1 {
+				t.Fatalf("entry action ran %d times and exit action %d, want %d and 1", calls, exits, tt.wantCalls)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithGuardRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		attempts  int
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "guard passes on a retry", attempts: 3, wantCalls: 3},
+		{name: "not enough attempts", attempts: 2, wantErr: statemachine.ErrGuardFailed, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			sm := statemachine.NewStateMachine("Waiting", statemachine.WithGuardRetry(tt.attempts, time.Millisecond))
+			sm.AddTransition("Waiting", "Ready", func() bool {
+				calls++
+				return calls >= 3
+			}, nil)
+
+			if err := sm.Transition("Ready"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("guard ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGuardRunsOncePerTransition(t *testing.T) {
+	calls := 0
+	sm := statemachine.NewStateMachine("Waiting")
+	sm.AddTransition("Waiting", "Ready", func() bool {
+		calls++
+		return true
+	}, nil)
+
+	if err := sm.Transition("Ready"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("guard ran %d times during one transition, want 1", calls)
+	}
+}
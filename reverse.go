@@ -0,0 +1,28 @@
+package statemachine
+
+import "maps"
+
+// build a new machine with the direction of every transition flipped, for walking a process backwards
+// (e.g. an undo workflow). only the shape of the graph carries over: guards, actions and events are
+// written for the forward direction, so the reversed transitions have none, and the original's entry,
+// exit and other per-state actions, timeouts and substate relationships aren't copied either. transition
+// metadata and allowed self-transitions are kept. wildcard transitions have no single source to point
+// back to, so they're left out. the reversed machine starts in the original's initial state - set
+// `InitialState` and call Reset to start it somewhere else
+func (sm *StateMachine) Reversed() *StateMachine {
+	reversed := NewStateMachine(sm.InitialState, WithStateComparator(sm.equal))
+	reversed.remember(sm.order...)
+
+	for _, t := range sm.orderedTransitions() {
+		if sm.sameState(t.From, AnyState) {
+			continue
+		}
+		reversed.addTransition(Transition{
+			From:      t.To,
+			To:        t.From,
+			AllowSelf: t.AllowSelf,
+			Metadata:  maps.Clone(t.Metadata),
+		})
+	}
+	return reversed
+}
@@ -0,0 +1,49 @@
+package statemachine_test
+
+import (
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestReversed(t *testing.T) {
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddTransition("Draft", "Review", func() bool { return false }, nil)
+	sm.AddTransitionWithMeta("Review", "Published", nil, nil, map[string]string{"role": "editor"})
+	sm.AddTransition(statemachine.AnyState, "Archived", nil, nil)
+
+	reversed := sm.Reversed()
+	want := map[statemachine.State][]statemachine.State{
+		"Review":    {"Draft"},
+		"Published": {"Review"},
+	}
+	if got := reversed.TransitionsCopy(); !equalTargets(got, want) {
+		t.Fatalf("reversed transitions = %v, want %v", got, want)
+	}
+
+	reversed.ForceState("Review")
+	if err := reversed.Transition("Draft"); err != nil {
+		t.Fatalf("the reversed transition kept its guard: %v", err)
+	}
+	if tr, ok := reversed.Transitions["Published"]; !ok || tr[0].Metadata["role"] != "editor" {
+		t.Fatal("the reversed transition lost its metadata")
+	}
+}
+
+// compare transition tables without caring about states that have no transitions
+func equalTargets(got, want map[statemachine.State][]statemachine.State) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for from, targets := range want {
+		if len(got[from]) != len(targets) {
+			return false
+		}
+		for i := range targets {
+			if got[from][i] != targets[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RollbackMode controls what happens when entering a state fails and the machine rolls back to
+// the state it was leaving
+type RollbackMode int
+
+const (
+	// RollbackStateOnly puts the machine back in the old state without running anything. this is
+	// the default
+	RollbackStateOnly RollbackMode = iota
+	// RollbackWithReentry puts the machine back in the old state and then re-runs that state's
+	// entry action, so anything its exit action tore down can be set up again
+	RollbackWithReentry
+)
+
+// set how the machine rolls back when an entry or post-entry action fails
+func (sm *StateMachine) SetRollbackMode(mode RollbackMode) {
+	sm.rollbackMode = mode
+}
+
+// return the machine to `oldState` after entering a new state failed with `cause`, and build the
+// error to hand back. the exit compensations of the `exited` states run first, then the state is
+// restored. when a compensation fails, or re-entry is enabled and the old state's entry action
+// fails too, those failures are joined into the returned error. a transition that has already been
+// abandoned by its timeout rolls nothing back: its caller has restored the state and the machine may
+// well have moved on since, so only the error is built
+func (sm *StateMachine) rollback(oldState State, exited []State, exec execution, cause error) error {
+	err := fmt.Errorf("%w: %v", ErrEntryActionFailed, cause)
+	if sm.abandoned(exec) {
+		return err
+	}
+	if compErr := sm.compensate(exited); compErr != nil {
+		err = errors.Join(err, compErr)
+	}
+	return sm.restoreState(oldState, exec, err)
+}
+
+// like restoreState, but for putting the machine back from outside a transition, e.g. when a series
+// of transitions is undone. any transition in progress is waited for first
+func (sm *StateMachine) revert(s State, err error) error {
+	sm.beginTransition()
+	defer sm.drainQueue()
+	defer sm.endTransition()
+	return sm.restoreState(s, execution{}, err)
+}
+
+// put the machine back in `s` following the rollback mode, passing `err` through. if re-entering
+// `s` fails, that failure is joined onto `err`. nothing is changed once the transition behind `exec`
+// has been abandoned
+func (sm *StateMachine) restoreState(s State, exec execution, err error) error {
+	sm.mu.Lock()
+	if exec.deadline != nil && exec.deadline.abandoned {
+		sm.mu.Unlock()
+		return err
+	}
+	sm.State = s
+	sm.mu.Unlock()
+
+	if sm.rollbackMode == RollbackWithReentry {
+		if reentryErr := sm.runEntryAction(s); reentryErr != nil {
+			return errors.Join(err, fmt.Errorf("re-entering %s after rollback failed: %v", stateName(s), reentryErr))
+		}
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestRollbackMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    statemachine.RollbackMode
+		wantLog []string
+	}{
+		{name: "state only", mode: statemachine.RollbackStateOnly, wantLog: []string{"exit Idle"}},
+		{name: "with reentry", mode: statemachine.RollbackWithReentry, wantLog: []string{"exit Idle", "enter Idle"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			sm := statemachine.NewStateMachine("Idle")
+			sm.AddSimpleTransition("Idle", "Running")
+			sm.SetExitAction("Idle", logged(&log, "exit Idle"))
+			sm.SetEntryAction("Idle", logged(&log, "enter Idle"))
+			sm.SetEntryAction("Running", failing("no power"))
+			sm.SetRollbackMode(tt.mode)
+
+			if err := sm.Transition("Running"); !errors.Is(err, statemachine.ErrEntryActionFailed) {
+				t.Fatalf("Transition error = %v, want ErrEntryActionFailed", err)
+			}
+			if got := sm.CurrentState(); got != "Idle" {
+				t.Fatalf("state = %v, want Idle", got)
+			}
+			if !reflect.DeepEqual(log, tt.wantLog) {
+				t.Fatalf("ran %v, want %v", log, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestRollbackReentryFailure(t *testing.T) {
+	sm := statemachine.NewStateMachine("Idle")
+	sm.AddSimpleTransition("Idle", "Running")
+	sm.SetEntryAction("Idle", failing("still broken"))
+	sm.SetEntryAction("Running", failing("no power"))
+	sm.SetRollbackMode(statemachine.RollbackWithReentry)
+
+	err := sm.Transition("Running")
+	if !errors.Is(err, statemachine.ErrEntryActionFailed) || !containsAll(err.Error(), "no power", "re-entering Idle after rollback failed: still broken") {
+		t.Fatalf("Transition error = %v, want both failures", err)
+	}
+}
+
+func TestExitCompensation(t *testing.T) {
+	var log []string
+	sm := statemachine.NewStateMachine("Playing")
+	sm.AddSubstate("Active", "Playing")
+	sm.AddSimpleTransition("Playing", "Stopped")
+	sm.SetExitAction("Playing", logged(&log, "exit Playing"))
+	sm.SetExitAction("Active", logged(&log, "exit Active"))
+	sm.SetExitCompensation("Playing", logged(&log, "undo Playing"))
+	sm.SetExitCompensation("Active", failing("can't reopen"))
+	sm.SetEntryAction("Stopped", failing("disk full"))
+
+	err := sm.Transition("Stopped")
+	if !containsAll(err.Error(), "disk full", "compensating exit from Active failed: can't reopen") {
+		t.Fatalf("Transition error = %v, want the entry and compensation failures", err)
+	}
+	if want := []string{"exit Playing", "exit Active", "undo Playing"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("ran %v, want %v", log, want)
+	}
+	if got := sm.CurrentState(); got != "Playing" {
+		t.Fatalf("state = %v, want Playing", got)
+	}
+}
+
+func TestExitCompensationSkippedWhenExitFails(t *testing.T) {
+	compensated := false
+	sm := statemachine.NewStateMachine("Idle")
+	sm.AddSimpleTransition("Idle", "Running")
+	sm.SetExitAction("Idle", failing("busy"))
+	sm.SetExitCompensation("Idle", func() error {
+		compensated = true
+		return nil
+	})
+
+	if err := sm.Transition("Running"); !errors.Is(err, statemachine.ErrExitActionFailed) {
+		t.Fatalf("Transition error = %v, want ErrExitActionFailed", err)
+	}
+	if compensated {
+		t.Fatal("compensated an exit action that never succeeded")
+	}
+}
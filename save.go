@@ -0,0 +1,145 @@
+package statemachine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// the version of the document written by Save. bump it whenever the document changes shape
+const saveVersion = 1
+
+// the document written by Save: the machine's topology and its runtime position together
+type savedMachine struct {
+	Version     int               `json:"version"`
+	Initial     string            `json:"initial"`
+	Current     string            `json:"current"`
+	Transitions []savedTransition `json:"transitions"`
+	Substates   []savedSubstate   `json:"substates,omitempty"`
+	History     []encodedEntry    `json:"history"`
+}
+
+type savedTransition struct {
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	Event     string            `json:"event,omitempty"`
+	Priority  int               `json:"priority,omitempty"`
+	Weight    float64           `json:"weight,omitempty"`
+	AllowSelf bool              `json:"allowSelf,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+type savedSubstate struct {
+	Child  string `json:"child"`
+	Parent string `json:"parent"`
+}
+
+// write the whole machine - its topology, current state and history - to a single versioned JSON
+// document, so it can be rehydrated in one go with Load. states are written by name. guards and
+// actions are functions and can't be saved, so they're left out, and guards aren't evaluated
+func (sm *StateMachine) Save(w io.Writer) error {
+	current, history := sm.position()
+
+	saved := savedMachine{
+		Version:     saveVersion,
+		Initial:     stateName(sm.InitialState),
+		Current:     stateName(current),
+		Transitions: []savedTransition{},
+		History:     []encodedEntry{},
+	}
+	for _, t := range sm.orderedTransitions() {
+		saved.Transitions = append(saved.Transitions, savedTransition{
+			From:      stateName(t.From),
+			To:        stateName(t.To),
+			Event:     t.Event,
+			Priority:  t.Priority,
+			Weight:    t.Weight,
+			AllowSelf: t.AllowSelf,
+			Name:      t.Name,
+			Metadata:  t.Metadata,
+		})
+	}
+	children := make(map[State]bool, len(sm.parents))
+	for child := range sm.parents {
+		children[child] = true
+	}
+	for _, child := range sm.inOrder(children) {
+		saved.Substates = append(saved.Substates, savedSubstate{Child: stateName(child), Parent: stateName(sm.parents[sm.key(child)])})
+	}
+	for _, entry := range history {
+		saved.History = append(saved.History, encodedEntry{
+			From:   stateName(entry.From),
+			To:     stateName(entry.To),
+			Time:   entry.Time,
+			Forced: entry.Forced,
+		})
+	}
+
+	return json.NewEncoder(w).Encode(saved)
+}
+
+// replace the machine's topology, current state and history with a document written by Save. like
+// LoadFromJSON, the loaded states are plain strings ("*" being AnyState) and the loaded transitions have
+// no guards or actions; entry and exit actions already registered on the machine under those names are
+// kept. no actions run and no timeouts are armed. a document from a newer version of Save is rejected
+// with ErrSnapshotVersion, and a malformed one with ErrInvalidDefinition, leaving the machine untouched
+func (sm *StateMachine) Load(r io.Reader) error {
+	var saved savedMachine
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDefinition, err)
+	}
+	if saved.Version != saveVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, saved.Version)
+	}
+	if saved.Initial == "" || saved.Current == "" {
+		return fmt.Errorf("%w: missing initial or current state", ErrInvalidDefinition)
+	}
+	for _, t := range saved.Transitions {
+		if t.From == "" || t.To == "" {
+			return fmt.Errorf("%w: transition from %q to %q is missing a state", ErrInvalidDefinition, t.From, t.To)
+		}
+	}
+
+	state := func(name string) State {
+		if name == stateName(AnyState) {
+			return AnyState
+		}
+		return name
+	}
+
+	sm.Transitions = make(map[State][]Transition)
+	sm.parents = make(map[State]State)
+	sm.order, sm.keys, sm.remembered = nil, nil, make(map[State]bool)
+	sm.InitialState = state(saved.Initial)
+	sm.remember(sm.InitialState)
+	for _, t := range saved.Transitions {
+		from, to := state(t.From), state(t.To)
+		sm.remember(from, to)
+		k := sm.key(from)
+		sm.Transitions[k] = append(sm.Transitions[k], Transition{
+			From:      from,
+			To:        to,
+			Event:     t.Event,
+			Priority:  t.Priority,
+			Weight:    t.Weight,
+			AllowSelf: t.AllowSelf,
+			Name:      t.Name,
+			Metadata:  t.Metadata,
+		})
+	}
+	for _, s := range saved.Substates {
+		sm.AddSubstate(state(s.Parent), state(s.Child))
+	}
+
+	history := make([]HistoryEntry, 0, len(saved.History))
+	for _, entry := range saved.History {
+		history = append(history, HistoryEntry{From: state(entry.From), To: state(entry.To), Time: entry.Time, Forced: entry.Forced})
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.State = state(saved.Current)
+	sm.history = history
+	return nil
+}
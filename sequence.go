@@ -0,0 +1,34 @@
+package statemachine
+
+import "fmt"
+
+// perform a series of transitions in order, all or nothing. if any step fails, the machine is put back
+// in the state it was in before Sequence was called and the failing step's error is returned.
+//
+// only the current state is restored - exit, transition and entry actions that already ran for the
+// earlier steps are NOT undone, and their side effects remain. no actions are run while restoring
+// unless the rollback mode is RollbackWithReentry, in which case the starting state's entry action is
+// run again, just as it would be after a failed entry. history and visit counts keep the steps that
+// succeeded, and the starting state's timeout (if any) starts over
+func (sm *StateMachine) Sequence(targets ...State) error {
+	start := sm.current()
+
+	for i, to := range targets {
+		from := sm.current()
+		err := sm.Transition(to)
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("sequence step %d of %d from %s to %s failed: %w", i+1, len(targets), stateName(from), stateName(to), err)
+		if sm.sameState(from, start) {
+			// nothing has moved, so there's nothing to restore
+			return err
+		}
+
+		err = sm.revert(start, err)
+		sm.armTimeout(start)
+		return err
+	}
+	return nil
+}
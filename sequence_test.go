@@ -0,0 +1,33 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestSequence(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []statemachine.State
+		wantErr error
+		want    statemachine.State
+	}{
+		{name: "every step succeeds", targets: []statemachine.State{"Paid", "Shipped", "Delivered"}, want: "Delivered"},
+		{name: "first step fails", targets: []statemachine.State{"Shipped"}, wantErr: statemachine.ErrInvalidTransition, want: "Created"},
+		{name: "later step fails", targets: []statemachine.State{"Paid", "Shipped", "Created"}, wantErr: statemachine.ErrInvalidTransition, want: "Created"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOrderMachine()
+			if err := sm.Sequence(tt.targets...); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Sequence error = %v, want %v", err, tt.wantErr)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package statemachine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrSnapshotVersion is returned when reading a snapshot written in a format this version doesn't understand
+var ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+// the format version written as the first byte of every serialized snapshot. bump it whenever the
+// encoded document changes shape, and keep reading the older versions where possible
+const snapshotVersion byte = 1
+
+// Snapshot captures the runtime position of a state machine at a point in time. It holds no
+// references to the machine itself, so it can be kept around and compared against later on
+type Snapshot struct {
+	State     State          // the current state when the snapshot was taken
+	History   []HistoryEntry // the transitions made up to that point, oldest first
+	Available []State        // the transitions that were available from State at that point
+}
+
+// capture the machine's current runtime position. everything is read under a single lock, so the
+// snapshot is internally consistent even while other goroutines are transitioning the machine - the
+// available transitions always belong to the captured state rather than one it has since left.
+// guards are evaluated while the lock is held, so they must not call back into the machine
+func (sm *StateMachine) Snapshot() Snapshot {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return Snapshot{
+		State:     sm.State,
+		History:   sm.copyHistory(),
+		Available: sm.availableFrom(sm.State, true),
+	}
+}
+
+// move the machine back to a previously captured position, replacing its history. no actions are run
+// and no timeouts are armed. every state in the snapshot must be known to this machine's definition
+func (sm *StateMachine) Restore(snapshot Snapshot) error {
+	known := map[State]bool{}
+	for _, s := range sm.allStates() {
+		known[sm.key(s)] = true
+	}
+
+	if !known[sm.key(snapshot.State)] {
+		return fmt.Errorf("%w: %s", ErrUnknownState, stateName(snapshot.State))
+	}
+	for _, entry := range snapshot.History {
+		if !known[sm.key(entry.From)] || !known[sm.key(entry.To)] {
+			return fmt.Errorf("%w: history entry from %s to %s", ErrUnknownState, stateName(entry.From), stateName(entry.To))
+		}
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.State = snapshot.State
+	sm.history = append([]HistoryEntry(nil), snapshot.History...)
+	return nil
+}
+
+// the encoded form of a snapshot. states are written by name, and resolved against the machine's
+// definition when read back, since an arbitrary state value can't be decoded into its original type
+type encodedSnapshot struct {
+	State   string         `json:"state"`
+	History []encodedEntry `json:"history"`
+}
+
+type encodedEntry struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Time   time.Time `json:"time"`
+	Forced bool      `json:"forced,omitempty"`
+}
+
+// read the current state and history together under the lock. unlike Snapshot, no guards are
+// evaluated, so writers that only need the machine's position never run user code
+func (sm *StateMachine) position() (State, []HistoryEntry) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.State, sm.copyHistory()
+}
+
+// serialize the machine's current position and history to a stream, prefixed with a format version
+// byte. guards aren't evaluated, since the available transitions aren't written
+func (sm *StateMachine) WriteSnapshot(w io.Writer) error {
+	state, history := sm.position()
+
+	encoded := encodedSnapshot{State: stateName(state), History: []encodedEntry{}}
+	for _, entry := range history {
+		encoded.History = append(encoded.History, encodedEntry{
+			From:   stateName(entry.From),
+			To:     stateName(entry.To),
+			Time:   entry.Time,
+			Forced: entry.Forced,
+		})
+	}
+
+	if _, err := w.Write([]byte{snapshotVersion}); err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(encoded)
+}
+
+// restore the machine's position and history from a stream written by `WriteSnapshot`. the states
+// in the stream must all be known to this machine's definition, otherwise ErrUnknownState is returned
+// and the machine is left untouched
+func (sm *StateMachine) ReadSnapshot(r io.Reader) error {
+	version := make([]byte, 1)
+	if _, err := io.ReadFull(r, version); err != nil {
+		return fmt.Errorf("reading snapshot version: %w", err)
+	}
+	if version[0] != snapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, version[0])
+	}
+
+	var encoded encodedSnapshot
+	if err := json.NewDecoder(r).Decode(&encoded); err != nil {
+		return fmt.Errorf("decoding snapshot: %w", err)
+	}
+
+	resolve := func(name string) (State, error) {
+		if s, ok := sm.lookupState(name); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrUnknownState, name)
+	}
+
+	var snapshot Snapshot
+	var err error
+	if snapshot.State, err = resolve(encoded.State); err != nil {
+		return err
+	}
+	for _, entry := range encoded.History {
+		from, err := resolve(entry.From)
+		if err != nil {
+			return err
+		}
+		to, err := resolve(entry.To)
+		if err != nil {
+			return err
+		}
+		snapshot.History = append(snapshot.History, HistoryEntry{From: from, To: to, Time: entry.Time, Forced: entry.Forced})
+	}
+
+	return sm.Restore(snapshot)
+}
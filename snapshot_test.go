@@ -0,0 +1,231 @@
+package statemachine_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	statemachine "github.com/jwald3/lollipop"
+	"github.com/jwald3/lollipop/statemachinetest"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	sm := newOrderMachine()
+	sm.Transition("Paid")
+	snapshot := sm.Snapshot()
+
+	if snapshot.State != "Paid" || len(snapshot.History) != 1 {
+		t.Fatalf("snapshot = %+v, want Paid with one history entry", snapshot)
+	}
+	if want := []statemachine.State{"Shipped", "Cancelled"}; !reflect.DeepEqual(snapshot.Available, want) {
+		t.Fatalf("snapshot.Available = %v, want %v", snapshot.Available, want)
+	}
+
+	sm.Transition("Shipped")
+	sm.Transition("Delivered")
+	if err := sm.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if sm.CurrentState() != "Paid" || len(sm.History()) != 1 {
+		t.Fatalf("restored to %v with %d history entries, want Paid with 1", sm.CurrentState(), len(sm.History()))
+	}
+
+	if err := sm.Restore(statemachine.Snapshot{State: "Lost"}); !errors.Is(err, statemachine.ErrUnknownState) {
+		t.Fatalf("Restore to an unknown state error = %v, want ErrUnknownState", err)
+	}
+	if got := sm.CurrentState(); got != "Paid" {
+		t.Fatalf("a rejected Restore moved the machine to %v", got)
+	}
+}
+
+func TestWriteReadSnapshot(t *testing.T) {
+	sm := newOrderMachine()
+	sm.SetClock(statemachinetest.NewFakeClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	sm.Transition("Paid")
+	sm.Transition("Shipped")
+
+	var buf bytes.Buffer
+	if err := sm.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newOrderMachine()
+	if err := restored.ReadSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if restored.CurrentState() != "Shipped" || !reflect.DeepEqual(restored.History(), sm.History()) {
+		t.Fatalf("read back %v with history %v, want Shipped with %v", restored.CurrentState(), restored.History(), sm.History())
+	}
+}
+
+// writing a snapshot or saving the machine only reads its position, so guards never run
+func TestWritersDoNotEvaluateGuards(t *testing.T) {
+	calls := 0
+	sm := statemachine.NewStateMachine("Created")
+	sm.AddTransition("Created", "Paid", func() bool {
+		calls++
+		return true
+	}, nil)
+
+	var buf bytes.Buffer
+	if err := sm.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Fatalf("guard ran %d times, want 0", calls)
+	}
+}
+
+func TestSnapshotConsistentUnderConcurrentTransitions(t *testing.T) {
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddBidirectional("Off", "On")
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					sm.TryTransition("On")
+					sm.TryTransition("Off")
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 1000; i++ {
+		snapshot := sm.Snapshot()
+		if n := len(snapshot.History); n > 0 && snapshot.History[n-1].To != snapshot.State {
+			t.Fatalf("snapshot in %v, but its last history entry went to %v", snapshot.State, snapshot.History[n-1].To)
+		}
+		want := statemachine.State("On")
+		if snapshot.State == "On" {
+			want = "Off"
+		}
+		if len(snapshot.Available) != 1 || snapshot.Available[0] != want {
+			t.Fatalf("snapshot in %v lists %v as available, want [%v]", snapshot.State, snapshot.Available, want)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestReadSnapshotErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{name: "unknown version", input: "\x02{}", wantErr: statemachine.ErrSnapshotVersion},
+		{name: "unknown state", input: "\x01" + `{"state":"Lost","history":[]}`, wantErr: statemachine.ErrUnknownState},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOrderMachine()
+			if err := sm.ReadSnapshot(strings.NewReader(tt.input)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadSnapshot error = %v, want %v", err, tt.wantErr)
+			}
+			if got := sm.CurrentState(); got != "Created" {
+				t.Fatalf("a rejected snapshot moved the machine to %v", got)
+			}
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	sm := newOrderMachine()
+	sm.AddSubstate("Fulfilment", "Shipped")
+	sm.AddEventTransition("Shipped", "lost", "Cancelled")
+	sm.Transition("Paid")
+
+	var buf bytes.Buffer
+	if err := sm.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := statemachine.NewStateMachine("Unused")
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !sm.Equal(loaded) {
+		t.Fatalf("loaded machine differs: %v", sm.Diff(loaded))
+	}
+	if loaded.CurrentState() != "Paid" || len(loaded.History()) != 1 {
+		t.Fatalf("loaded %v with %d history entries, want Paid with 1", loaded.CurrentState(), len(loaded.History()))
+	}
+	if err := loaded.Transition("Shipped"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{name: "malformed", input: "{", wantErr: statemachine.ErrInvalidDefinition},
+		{name: "newer version", input: `{"version":2,"initial":"A","current":"A"}`, wantErr: statemachine.ErrSnapshotVersion},
+		{name: "missing state", input: `{"version":1,"initial":"A","current":"A","transitions":[{"from":"A"}]}`, wantErr: statemachine.ErrInvalidDefinition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOrderMachine()
+			if err := sm.Load(strings.NewReader(tt.input)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load error = %v, want %v", err, tt.wantErr)
+			}
+			if len(sm.Transitions) == 0 {
+				t.Fatal("a rejected document replaced the machine's transitions")
+			}
+		})
+	}
+}
+
+func TestLoadFromJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{
+			name:  "valid definition",
+			input: `{"initial":"Draft","transitions":[{"from":"Draft","to":"Review"},{"from":"Review","to":"Approved"}]}`,
+		},
+		{name: "malformed", input: `{"initial":`, wantErr: statemachine.ErrInvalidDefinition},
+		{name: "no initial state", input: `{"transitions":[{"from":"Draft","to":"Review"}]}`, wantErr: statemachine.ErrInvalidDefinition},
+		{name: "initial state unused", input: `{"initial":"Start","transitions":[{"from":"Draft","to":"Review"}]}`, wantErr: statemachine.ErrInvalidDefinition},
+		{name: "missing state", input: `{"initial":"Draft","transitions":[{"from":"Draft"}]}`, wantErr: statemachine.ErrInvalidDefinition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, err := statemachine.LoadFromJSON(strings.NewReader(tt.input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadFromJSON error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := sm.Transition("Review"); err != nil {
+				t.Fatal(err)
+			}
+			if err := sm.Transition("Approved"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package statemachine
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// the directory holding this package's source, used to skip over our own frames when looking for the caller
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// WithSourceTracking records the file and line that registered each transition, which can then be
+// looked up with `TransitionSource`. This is meant for debugging large or generated machines, where
+// it's not obvious where a surprising edge came from, and adds a little overhead to every registration.
+func WithSourceTracking() Option {
+	return func(sm *StateMachine) {
+		sm.trackSources = true
+	}
+}
+
+// return the file:line at which the transition between two states was registered. an empty string is
+// returned if the transition doesn't exist or was registered while source tracking was disabled
+func (sm *StateMachine) TransitionSource(from, to State) string {
+	for _, t := range sm.Transitions[sm.key(from)] {
+		if sm.sameState(t.To, to) {
+			return t.source
+		}
+	}
+	return ""
+}
+
+// find the first frame on the stack that belongs to the user's code rather than this package, so that
+// registrations made through helpers like `AddSimpleTransition` or the builder report the user's call site.
+// the package's own tests live alongside it, so they count as user code
+func callerOutsidePackage() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		ours := filepath.Dir(frame.File) == packageDir && !strings.HasSuffix(frame.File, "_test.go")
+		if !ours {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
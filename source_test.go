@@ -0,0 +1,24 @@
+package statemachine_test
+
+import (
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestTransitionSource(t *testing.T) {
+	tracked := statemachine.NewStateMachine("Off", statemachine.WithSourceTracking())
+	tracked.AddSimpleTransition("Off", "On")
+	untracked := statemachine.NewStateMachine("Off")
+	untracked.AddSimpleTransition("Off", "On")
+
+	if src := tracked.TransitionSource("Off", "On"); !containsAll(src, "source_test.go:") {
+		t.Fatalf("TransitionSource = %q, want this file", src)
+	}
+	if src := untracked.TransitionSource("Off", "On"); src != "" {
+		t.Fatalf("TransitionSource without tracking = %q, want empty", src)
+	}
+	if src := tracked.TransitionSource("On", "Off"); src != "" {
+		t.Fatalf("TransitionSource for a missing transition = %q, want empty", src)
+	}
+}
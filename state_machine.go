@@ -3,6 +3,9 @@ package statemachine
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"sync"
+	"time"
 )
 
 // Common errors that may be returned by the state machine
@@ -10,6 +13,11 @@ var (
 	ErrInvalidTransition = errors.New("invalid state transition")
 	ErrEntryActionFailed = errors.New("entry action failed")
 	ErrExitActionFailed  = errors.New("exit action failed")
+	ErrPrepareFailed     = errors.New("two-phase prepare failed")
+	ErrInvalidDefinition = errors.New("invalid state machine definition")
+	ErrUnknownState      = errors.New("unknown state")
+	ErrSelfTransition    = errors.New("self-transition not allowed")
+	ErrGuardFailed       = errors.New("guard condition failed")
 )
 
 // State represents any value that can be used as a state - you are expected to enforce a valid
@@ -23,149 +31,1059 @@ type Action func() error
 // A transition should fail if the guard condition is not satisfied.
 type Guard func() bool
 
+// PayloadGuard is a guard that receives the payload passed to `TransitionWith`
+type PayloadGuard func(payload any) bool
+
+// GuardFull is a guard that is told which state the machine is in and which state it's being asked
+// to move to, so one policy function can be shared between several transitions
+type GuardFull func(from, to State) bool
+
+// ResultAction is a transition action that produces a value, e.g. the ID of a generated receipt
+type ResultAction func() (any, error)
+
+// GuardErr is a guard that can explain why it failed. returning false with a nil error rejects the
+// transition like any other guard, while a non-nil error rejects it and is passed on to the caller
+type GuardErr func() (bool, error)
+
+// PayloadAction is a transition action that receives the payload passed to `TransitionWith`
+type PayloadAction func(payload any) error
+
 // transitions include both the target state and a guard function to control the transition
 type Transition struct {
 	From   State
 	To     State
 	Guard  Guard
 	Action Action
+	Event  string // the name of the event that triggers this transition, if it was registered as one
+
+	source string // the file:line the transition was registered from, when source tracking is enabled
+
+	Guards        []Guard       // additional guards, all of which must pass alongside Guard
+	AllowSelf     bool          // whether this transition may be taken when the machine is already in its target state
+	Priority      int           // transitions with a higher priority are considered first, see AddTransitionWithPriority
+	Weight        float64       // how likely StepRandom is to pick the transition, see AddWeightedTransition
+	PayloadGuard  PayloadGuard  // like Guard, but receives the payload the transition was triggered with
+	PayloadAction PayloadAction // like Action, but receives the payload the transition was triggered with
+	GuardFull     GuardFull     // like Guard, but receives the current and target states
+	GuardErr      GuardErr      // like Guard, but may return an error explaining why it couldn't be evaluated
+	GuardHist     GuardHist     // like Guard, but receives a copy of the machine's transition history
+	ResultAction  ResultAction  // like Action, but produces a value handed back by TransitionResult
+	Name          string        // an optional name for the transition, see AddNamedTransition
+
+	Metadata map[string]string // arbitrary key/value tags for tooling, e.g. "role": "admin"
 }
 
 // StateMachine manages state transitions and their associated actions
 type StateMachine struct {
-	State        State                  // a reference to the current state at a given time
-	Transitions  map[State][]Transition // defines the valid transitions allowed from one state to another
+	State        State                  // the current state; read it with CurrentState when other goroutines may be transitioning
+	Transitions  map[State][]Transition // defines the valid transitions allowed from one state to another; change it through the Add methods
 	InitialState State                  // the state used in `Reset()` calls
 	entryActions map[State]Action       // the functions called when entering a state
 	exitActions  map[State]Action       // the functions called when exiting a state
+
+	postEntryActions map[State]Action     // the functions called once all entry actions have succeeded
+	entryActionsFrom map[entryPair]Action // entry actions that only apply when coming from a particular state
+	entryGuards      map[State]Guard      // conditions that must hold to enter a state, whichever way it's reached
+	reentryActions   map[State]Action     // the functions called on a self-transition instead of exit and entry actions
+
+	exitCompensations map[State]Action // the functions that undo an exit action when a transition rolls back
+
+	predicates map[State][]predicateTransition // transitions to any target matching a predicate
+
+	twoPhaseActions map[State]TwoPhaseAction     // transactional actions prepared and committed around a transition
+	candidateFilter CandidateFilter              // optionally narrows or reorders the transitions considered from a state
+	beforeCommit    func(from, to State) error   // called just before the current state is changed
+	beforeHooks     []func(from, to State) error // policies that may veto any transition before it starts
+	logger          Logger                       // traces every transition attempt
+	events          *jsonEventLogger             // writes every transition attempt as JSON, alongside the logger
+	recorder        Recorder                     // collects metrics about transitions and actions
+	parents         map[State]State              // the parent of each substate
+	trackSources    bool                         // whether to record where each transition was registered
+	guardAttempts   int                          // how many times a failing guard is evaluated before giving up
+	guardBackoff    time.Duration                // how long to wait between guard evaluations
+	timeouts        map[State]timeout            // automatic transitions that fire after spending a while in a state
+	debounces       map[State]time.Duration      // minimum time between runs of a state's entry action
+	clock           Clock                        // the source of time for timeouts and debouncing
+	order           []State                      // every registered state, in the order it was first seen
+	keys            []State                      // the key each state in `order` is stored under in the tables
+	remembered      map[State]bool               // the keys of the states already listed in `order`
+	rollbackMode    RollbackMode                 // what to run when entering a state fails and the machine rolls back
+
+	defaultHandler func(from, to State) (State, error) // decides where unmatched transitions go, if anywhere
+	equal          func(a, b State) bool               // compares states when matching transitions, == if nil
+	allowUnknown   bool                                // whether ForceState accepts states outside the definition
+
+	transitionTimeout time.Duration  // how long a transition's actions may run before it's abandoned
+	registered        map[State]bool // the states declared with RegisterState
+	strict            bool           // whether transitions may only use registered states
+	nonTerminal       map[State]bool // the states Validate requires to have a way out
+
+	afterHooks    []func(sm *StateMachine, from, to State) // run after every successful transition
+	maxChainDepth int                                      // how many transitions after-transition hooks may chain
+
+	cacheGuards bool // whether AvailableTransitions evaluates each shared guard once per call
+
+	maxTransitions int // how many successful transitions the machine may make before a reset, 0 for unlimited
+
+	serial  sync.Mutex      // held while a transition is matched, checked and run, so only one runs at a time
+	mu      sync.RWMutex    // guards the current state and runtime bookkeeping shared with background timers
+	pending *pendingTimeout // the timeout armed for the current state, if any
+	subs    subscribers     // channels notified of every successful transition
+
+	lastEntryRun map[State]time.Time // when each debounced entry action last ran
+	history      []HistoryEntry      // every successful transition, oldest first
+	visits       map[State]int       // how many times each state has been entered
+	coverage     map[[2]State]bool   // the transitions taken since coverage was enabled, nil while disabled
+	chainDepth   int                 // how many after-transition hooks are currently running
+	busy         bool                // whether a transition holds the reservation taken by beginTransition
+	queue        []State             // transitions waiting for the machine to be idle, oldest first
+	draining     bool                // whether queued transitions are being run
+	steps        int                 // how many successful transitions have been made since the last reset
 }
 
-func NewStateMachine(initialState State) *StateMachine {
-	return &StateMachine{
+// Option configures optional behavior of a state machine when it is created
+type Option func(*StateMachine)
+
+func NewStateMachine(initialState State, opts ...Option) *StateMachine {
+	sm := &StateMachine{
 		State:        initialState,
 		Transitions:  make(map[State][]Transition), // These properties use methods to set their values explicitly.
 		InitialState: initialState,
 		entryActions: make(map[State]Action), // ---
 		exitActions:  make(map[State]Action), // ---
+
+		postEntryActions: make(map[State]Action),
+		entryActionsFrom: make(map[entryPair]Action),
+		entryGuards:      make(map[State]Guard),
+		reentryActions:   make(map[State]Action),
+
+		exitCompensations: make(map[State]Action),
+
+		predicates: make(map[State][]predicateTransition),
+
+		twoPhaseActions: make(map[State]TwoPhaseAction),
+		timeouts:        make(map[State]timeout),
+		debounces:       make(map[State]time.Duration),
+		parents:         make(map[State]State),
+		clock:           realClock{},
+		logger:          noopLogger{},
+		recorder:        noopRecorder{},
+		remembered:      make(map[State]bool),
+		registered:      make(map[State]bool),
+		nonTerminal:     make(map[State]bool),
+		maxChainDepth:   defaultMaxChainDepth,
+
+		lastEntryRun: make(map[State]time.Time),
+		visits:       make(map[State]int),
 	}
+
+	for _, opt := range opts {
+		opt(sm)
+	}
+	sm.remember(initialState)
+	sm.visits[sm.key(initialState)] = 1
+
+	return sm
 }
 
 // add transitions to the state machine's registry. if a state is not present in the map of
-// transitions, we will add it and its "to" state
-func (sm *StateMachine) AddTransition(from, to State, guard Guard, action Action) {
+// transitions, we will add it and its "to" state. registering the same from/to pair again replaces
+// the earlier transition, so the latest guard and action win. the machine is returned so calls
+// can be chained
+func (sm *StateMachine) AddTransition(from, to State, guard Guard, action Action) *StateMachine {
+	sm.addTransition(Transition{
+		From:   from,
+		To:     to,
+		Guard:  guard,
+		Action: action,
+	})
+	return sm
+}
+
+// add a transition whose guard and action receive the payload given to `TransitionWith`. when the
+// transition is triggered through `Transition` or `Fire`, they receive a nil payload
+func (sm *StateMachine) AddTransitionWithPayload(from, to State, guard PayloadGuard, action PayloadAction) {
+	sm.addTransition(Transition{
+		From:          from,
+		To:            to,
+		PayloadGuard:  guard,
+		PayloadAction: action,
+	})
+}
+
+// add a transition whose guard receives the current and target states. `from` is the machine's actual
+// state, which differs from the transition's source for transitions inherited from a parent state or
+// registered from AnyState
+func (sm *StateMachine) AddTransitionFull(from, to State, guard GuardFull, action Action) {
+	sm.addTransition(Transition{
+		From:      from,
+		To:        to,
+		GuardFull: guard,
+		Action:    action,
+	})
+}
+
+// add a transition whose guard may return an error. a guard returning false rejects the transition
+// with ErrGuardFailed, and an error from the guard rejects it with an error wrapping both
+// ErrGuardFailed and the guard's own error
+func (sm *StateMachine) AddTransitionGuardErr(from, to State, guard GuardErr, action Action) {
+	sm.addTransition(Transition{
+		From:     from,
+		To:       to,
+		GuardErr: guard,
+		Action:   action,
+	})
+}
+
+// add a transition whose action produces a value, which `TransitionResult` hands back to the caller
+// once the transition has succeeded
+func (sm *StateMachine) AddTransitionR(from, to State, action func() (any, error)) {
+	sm.addTransition(Transition{
+		From:         from,
+		To:           to,
+		ResultAction: action,
+	})
+}
+
+// add a transition carrying key/value metadata, e.g. the role allowed to take it, for tooling and
+// policy layers to read back through FindTransition or TransitionMap. the metadata is copied, so
+// changing the map afterwards has no effect on the machine
+func (sm *StateMachine) AddTransitionWithMeta(from, to State, guard Guard, action Action, meta map[string]string) {
+	sm.addTransition(Transition{
+		From:     from,
+		To:       to,
+		Guard:    guard,
+		Action:   action,
+		Metadata: maps.Clone(meta),
+	})
+}
+
+// every registration funnels through here. a transition that duplicates an existing one (same
+// source, target, and event) replaces it in place rather than being appended, so guards never get
+// evaluated redundantly and the original ordering is kept
+func (sm *StateMachine) addTransition(t Transition) {
+	sm.checkRegistered(t)
+	if sm.trackSources {
+		t.source = callerOutsidePackage()
+	}
+	sm.remember(t.From, t.To)
+
+	from := sm.key(t.From)
 	if sm.Transitions[from] == nil {
 		sm.Transitions[from] = []Transition{}
 	}
-	sm.Transitions[from] = append(sm.Transitions[from], Transition{
+
+	for i, existing := range sm.Transitions[from] {
+		if sm.sameState(existing.To, t.To) && existing.Event == t.Event {
+			sm.Transitions[from][i] = t
+			return
+		}
+	}
+	sm.Transitions[from] = append(sm.Transitions[from], t)
+}
+
+// register a transition from a state back to itself. an ordinary transition is never taken when the
+// machine is already in its target state (`Transition` returns ErrSelfTransition instead), which guards
+// against accidental self-loops firing side effects twice. a self-transition registered here is allowed,
+// and runs the state's exit action followed by its entry action just like any other transition
+func (sm *StateMachine) AddSelfTransition(state State, guard Guard, action Action) {
+	sm.addTransition(Transition{
+		From:      state,
+		To:        state,
+		Guard:     guard,
+		Action:    action,
+		AllowSelf: true,
+	})
+}
+
+// add a transition with a priority. when several transitions out of a state compete - e.g. for the
+// same event - they're considered in descending priority order and the highest-priority one whose
+// guard passes is chosen. transitions with equal priority keep their registration order, and
+// transitions added any other way have a priority of zero
+func (sm *StateMachine) AddTransitionWithPriority(from, to State, priority int, guard Guard) {
+	sm.addTransition(Transition{
+		From:     from,
+		To:       to,
+		Guard:    guard,
+		Priority: priority,
+	})
+}
+
+// add a transition that is only allowed when every one of the given guards passes. guards are
+// checked in order and evaluation stops at the first one that fails
+func (sm *StateMachine) AddGuardedTransition(from, to State, guards ...Guard) {
+	sm.addTransition(Transition{
 		From:   from,
 		To:     to,
-		Guard:  guard,
-		Action: action,
+		Guards: guards,
 	})
 }
 
-// add a transition without a guard or action attached to it
-func (sm *StateMachine) AddSimpleTransition(from, to State) {
-	sm.AddTransition(from, to, nil, nil)
+// add a transition without a guard or action attached to it, returning the machine for chaining
+func (sm *StateMachine) AddSimpleTransition(from, to State) *StateMachine {
+	return sm.AddTransition(from, to, nil, nil)
 }
 
-func (sm *StateMachine) CanTransition(to State) bool {
-	transitions, exists := sm.Transitions[sm.State]
-	// if the current state isn't included in the transaction definitions, you cannot
-	// transition to any state.
-	if !exists {
-		return false
+// add simple transitions from one state to each of the given targets
+func (sm *StateMachine) AddTransitions(from State, tos ...State) {
+	for _, to := range tos {
+		sm.AddSimpleTransition(from, to)
 	}
+}
 
-	// loop over the valid transition options until a match or the end of the list
-	for _, transition := range transitions {
-		if transition.To == to {
-			if transition.Guard != nil {
-				return transition.Guard()
-			}
+// add simple transitions in both directions between two states, e.g. a light switch going
+// between on and off
+func (sm *StateMachine) AddBidirectional(a, b State) {
+	sm.AddSimpleTransition(a, b)
+	sm.AddSimpleTransition(b, a)
+}
 
-			return true
+// remove every transition from one state to another, whatever its event, returning whether anything
+// was removed. when the last transition out of a state is removed, the state's entry is deleted from
+// `Transitions` altogether, so a state never lingers with an empty list
+func (sm *StateMachine) RemoveTransition(from, to State) bool {
+	from = sm.key(from)
+	transitions := sm.Transitions[from]
+	kept := make([]Transition, 0, len(transitions))
+	for _, t := range transitions {
+		if !sm.sameState(t.To, to) {
+			kept = append(kept, t)
 		}
 	}
+	if len(kept) == len(transitions) {
+		return false
+	}
+
+	if len(kept) == 0 {
+		delete(sm.Transitions, from)
+	} else {
+		sm.Transitions[from] = kept
+	}
+	return true
+}
+
+// remove every transition out of a state, deleting its entry from `Transitions` just like removing
+// its last transition with RemoveTransition would
+func (sm *StateMachine) ClearTransitions(from State) {
+	delete(sm.Transitions, sm.key(from))
+}
+
+// report whether a transition to the given state would currently be allowed. this evaluates the
+// transition's guards, and `Transition` evaluates them again when it's called, so checking with
+// CanTransition first means guards run twice - and may give different answers each time. when guards
+// are expensive or have side effects, call `Transition` directly and inspect the error instead
+func (sm *StateMachine) CanTransition(to State) bool {
+	ok, _ := sm.CanTransitionReason(to)
+	return ok
+}
+
+// like CanTransition, but when the transition isn't allowed it also explains why, in words suitable
+// for showing to a user - e.g. "no transition defined to this state" or "the transition's guard was
+// not satisfied". the reason is empty when the transition is allowed
+func (sm *StateMachine) CanTransitionReason(to State) (bool, string) {
+	from := sm.current()
+	transition, r := sm.lookup(from, to)
+	if r == accepted {
+		r = sm.precheck(transition, from)
+	}
+	return r == accepted, r.reason()
+}
+
+// decide whether a matched transition may be taken, like `inspect` but evaluating each guard just
+// once, whatever the guard retry settings
+func (sm *StateMachine) precheck(t Transition, from State) rejection {
+	switch {
+	case sm.selfRejected(t, from):
+		return rejectSelf
+	case !t.guardPasses(from, guardInput{machine: sm}):
+		return rejectGuard
+	case !sm.entryAllowed(t.To):
+		return rejectEntryGuard
+	default:
+		return accepted
+	}
+}
 
-	return false
+// list the states that can currently be transitioned to, i.e. the targets of the current state's
+// transitions whose guards pass right now. each target appears once, highest priority first and
+// otherwise in registration order
+func (sm *StateMachine) AvailableTransitions() []State {
+	return sm.availableFrom(sm.current(), false)
+}
+
+// report whether the machine is stuck in its current state, i.e. no transition out of it is available
+// right now - either none are defined or every one of their guards currently fails. unlike
+// `TerminalStates`, which looks at the definition alone, this is evaluated against the guards at runtime,
+// which makes it handy for "keep processing until done" loops
+func (sm *StateMachine) IsTerminal() bool {
+	return len(sm.AvailableTransitions()) == 0
+}
+
+// the targets reachable from the given state whose guards pass right now. `locked` says whether the
+// caller already holds the lock, in which case the history is read for GuardHist guards without it
+func (sm *StateMachine) availableFrom(from State, locked bool) []State {
+	in := guardInput{machine: sm, locked: locked}
+	if sm.cacheGuards {
+		in.cache = guardCache{}
+	}
+
+	var available []State
+	seen := map[State]bool{}
+	for _, t := range sm.candidates(from) {
+		if seen[sm.key(t.To)] || sm.selfRejected(t, from) || !t.guardPasses(from, in) || !sm.entryAllowed(t.To) {
+			continue
+		}
+		seen[sm.key(t.To)] = true
+		available = append(available, t.To)
+	}
+	return available
 }
 
 // go from one state to another, performing exit and entry actions where applicable.
 // the transition only sets the state machine's current status, so any intention to
-// use a state machine to update an object's status requires the use of entry/exit actions
+// use a state machine to update an object's status requires the use of entry/exit actions.
+// each guard on the matched transition is evaluated at most once per call (or up to the
+// configured number of attempts with WithGuardRetry). a transition started while another is in
+// progress waits for it to finish, so guards, actions and other callbacks must not call Transition on
+// the machine running them - it would wait for itself forever. they can use QueueTransition instead
 func (sm *StateMachine) Transition(to State) error {
-	transitions, exists := sm.Transitions[sm.State]
-	if !exists {
-		return fmt.Errorf("%w: from %v to %v", ErrInvalidTransition, sm.State, to)
+	return sm.TransitionWith(to, nil)
+}
+
+// transition to another state, handing the payload to the transition's guard and action. the payload
+// is passed through unchanged, so it's up to the guard and action to assert it to the type they expect.
+// transitions registered without a payload guard or action ignore the payload entirely. like Transition,
+// it mustn't be called from the callbacks of the machine's own transitions - use QueueTransition there
+func (sm *StateMachine) TransitionWith(to State, payload any) error {
+	sm.beginTransition()
+	from := sm.current()
+	target, err := sm.transitionWith(from, to, payload)
+	sm.endTransition()
+
+	return sm.report(from, target, err)
+}
+
+// the body of TransitionWith, run once the machine has been reserved for the transition. it returns
+// the state to report the attempt against, which is where the default handler sent it if it stepped in
+func (sm *StateMachine) transitionWith(from, to State, payload any) (State, error) {
+	matchedTransition, err := sm.match(from, to)
+	if err != nil && sm.defaultHandler != nil {
+		return sm.fallBack(from, to, payload, err)
+	}
+	if err == nil {
+		err = sm.perform(matchedTransition, execution{payload: payload})
+	}
+	return to, err
+}
+
+// transition to another state like `Transition`, returning the value produced by the transition's
+// ResultAction (see AddTransitionR) once the whole transition has succeeded. the value is nil for
+// transitions without one. when the transition fails at any point, including an entry action failing
+// after the result was produced, the error is returned and the value is discarded
+func (sm *StateMachine) TransitionResult(to State) (any, error) {
+	var result any
+	sm.beginTransition()
+	from := sm.current()
+	matchedTransition, err := sm.match(from, to)
+	if err == nil {
+		err = sm.perform(matchedTransition, execution{result: &result})
 	}
+	sm.endTransition()
+
+	if err := sm.report(from, to, err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// find the transition that would take the machine from one state to another, without checking its guard
+func (sm *StateMachine) match(from, to State) (Transition, error) {
+	t, r := sm.lookup(from, to)
+	if r != accepted {
+		return t, r.err(from, to)
+	}
+	return t, nil
+}
+
+// the allocation-free core of `match`, reporting why no transition was found instead of building an error
+func (sm *StateMachine) lookup(from, to State) (Transition, rejection) {
+	transitions := sm.candidates(from)
 
 	// attempt to find the requested transition between the current and target states
-	var matchedTransition *Transition
 	for _, t := range transitions {
-		if t.To == to {
-			matchedTransition = &t
-			break
+		if sm.sameState(t.To, to) {
+			return t, accepted
 		}
 	}
 
-	// if the transition could not be found, return an error
-	if matchedTransition == nil {
-		return fmt.Errorf("%w: from %v to %v", ErrExitActionFailed, sm.State, to)
+	// fall back on a predicate transition accepting the target
+	if t, ok := sm.matchPredicate(from, to); ok {
+		return t, accepted
+	}
+
+	// if the transition could not be found, say so
+	if len(transitions) == 0 {
+		return Transition{}, rejectNoTransitions
+	}
+	return Transition{}, rejectNoMatch
+}
+
+// reserve the machine for a transition, waiting for any transition already in progress to finish.
+// every way of starting a transition holds the reservation from before it reads the current state
+// until its actions have run, so transitions never interleave. it's released before the outcome is
+// reported, leaving after-transition hooks and queued transitions free to start transitions of their
+// own. guards, actions and the other callbacks run while it's held, so they mustn't start a transition
+// directly - that would wait forever - but can use QueueTransition instead. the machine counts as busy
+// for the queue for as long as the reservation is held
+func (sm *StateMachine) beginTransition() {
+	sm.serial.Lock()
+	sm.mu.Lock()
+	sm.busy = true
+	sm.mu.Unlock()
+}
+
+// release the reservation taken by beginTransition. whoever releases it is responsible for running
+// anything queued in the meantime, either through report or by calling drainQueue themselves
+func (sm *StateMachine) endTransition() {
+	sm.mu.Lock()
+	sm.busy = false
+	sm.mu.Unlock()
+	sm.serial.Unlock()
+}
+
+// carry out a transition that has already been matched against the current state: check its guard,
+// then run the exit action, the transition's own action, and the entry action, in that order
+func (sm *StateMachine) perform(matchedTransition Transition, exec execution) error {
+	if err := sm.admit(matchedTransition, exec.payload); err != nil {
+		return err
+	}
+
+	return sm.execute(matchedTransition, exec)
+}
+
+// execution holds the settings that differ between the various ways of triggering a transition
+type execution struct {
+	payload       any           // handed to payload guards and actions
+	entryAttempts int           // how many times to try each entry action before giving up
+	entryBackoff  time.Duration // how long to wait between entry action attempts
+	deadline      *deadline     // set when the transition runs under a timeout
+	result        *any          // where to put the value produced by a ResultAction, if anyone wants it
+}
+
+// decide whether a matched transition may be taken from the current state, without running anything
+func (sm *StateMachine) admit(matchedTransition Transition, payload any) error {
+	from := sm.current()
+	if r, guardErr := sm.inspect(matchedTransition, from, payload); r != accepted {
+		return r.errWith(guardErr, from, matchedTransition.To)
 	}
+	return nil
+}
 
-	// check the guard if present and return an error if it cannot be satisfied
-	if matchedTransition.Guard != nil && !matchedTransition.Guard() {
-		return fmt.Errorf("%w: guard condition failed", ErrInvalidTransition)
+// the allocation-free core of `admit`, for callers that only need to know whether it was rejected
+func (sm *StateMachine) check(matchedTransition Transition, from State, payload any) rejection {
+	r, _ := sm.inspect(matchedTransition, from, payload)
+	return r
+}
+
+// decide whether a matched transition may be taken, also returning the error a guard gave for
+// rejecting it, if any
+func (sm *StateMachine) inspect(matchedTransition Transition, from State, payload any) (rejection, error) {
+	// going nowhere is only allowed for transitions that explicitly opted in
+	if sm.selfRejected(matchedTransition, from) {
+		return rejectSelf, nil
+	}
+
+	// check the guard if present and reject the transition if it cannot be satisfied. this is the only
+	// place a transition's guards are evaluated on the way to executing it, so they never run twice
+	if ok, err := sm.checkGuard(matchedTransition, from, payload); !ok {
+		return rejectGuard, err
 	}
 
+	// then the target state's own entry guard, which applies however the state is reached
+	if !sm.entryAllowed(matchedTransition.To) {
+		return rejectEntryGuard, nil
+	}
+
+	return accepted, nil
+}
+
+// run the actions of a transition whose guard has already been satisfied, within the transition
+// timeout if one is set
+func (sm *StateMachine) execute(matchedTransition Transition, exec execution) error {
+	if sm.transitionTimeout <= 0 {
+		return sm.runTransition(matchedTransition, exec)
+	}
+	return sm.executeWithin(matchedTransition, exec)
+}
+
+// the body of `execute`
+func (sm *StateMachine) runTransition(matchedTransition Transition, exec execution) (err error) {
+	to := matchedTransition.To
+
 	// preserve the current state if you need to roll back later
-	oldState := sm.State
+	oldState := sm.current()
 
-	// check for entry actions, if there is one and it cannot be performed,  return the error
-	if exitAction := sm.exitActions[sm.State]; exitAction != nil {
-		if err := exitAction(); err != nil {
-			return fmt.Errorf("%w: %v", ErrExitActionFailed, err)
+	// stop after-transition hooks from chaining transitions without end
+	if err := sm.checkChainDepth(oldState, to); err != nil {
+		return err
+	}
+
+	// stop a runaway machine once it has used up its transition budget
+	if err := sm.checkBudget(oldState, to); err != nil {
+		return err
+	}
+
+	// give the before-transition hooks a chance to veto before anything has been run
+	for _, hook := range sm.beforeHooks {
+		if err := hook(oldState, to); err != nil {
+			return err
+		}
+	}
+
+	// prepare any two-phase actions before anything else runs. if one of them can't be prepared,
+	// every participant is aborted and the machine is left untouched. once prepared, they are
+	// committed or aborted together depending on how the rest of the transition goes
+	participants := sm.twoPhaseParticipants(oldState, to)
+	if err := prepareAll(participants); err != nil {
+		return fmt.Errorf("%w: %v", ErrPrepareFailed, err)
+	}
+	defer func() {
+		if err != nil {
+			abortAll(participants)
+			return
+		}
+		commitAll(participants)
+	}()
+
+	// a self-transition into a state with a reentry action runs that instead of the exit and entry actions
+	reentry := sm.reentryActionFor(oldState, to)
+
+	// check for exit actions, if there is one and it cannot be performed, return the error.
+	// when leaving a substate, its parents are exited too (innermost first) unless the target
+	// is still inside them
+	exiting := sm.exitChain(oldState, to)
+	if reentry != nil {
+		exiting = nil
+	}
+	var exited []State // the states whose exit actions ran, for compensating on rollback
+	for _, exiting := range exiting {
+		if exitAction := sm.exitActions[sm.key(exiting)]; exitAction != nil {
+			if err := sm.timed(exiting, exitAction); err != nil {
+				return fmt.Errorf("%w: %v", ErrExitActionFailed, err)
+			}
+			exited = append(exited, exiting)
 		}
 	}
 
 	// attempt to perform the transition action. if the action fails, return the error.
 	// you do not need to roll back because the state has not yet been altered.
-	if matchedTransition.Action != nil {
-		if err := matchedTransition.Action(); err != nil {
-			return fmt.Errorf("transition action failed: %v", err)
+	if err := safely(func() error { return matchedTransition.runAction(exec) }); err != nil {
+		return fmt.Errorf("transition action failed: %v", err)
+	}
+
+	// give the before-commit hook a final chance to veto now that the exit and transition
+	// actions are known to have succeeded. the state is untouched, so there's nothing to roll back
+	if sm.beforeCommit != nil {
+		if err := sm.beforeCommit(oldState, to); err != nil {
+			return fmt.Errorf("transition rejected before commit: %w", err)
 		}
 	}
 
-	// set the current state to the target state
-	sm.State = to
+	// set the current state to the target state, unless the transition timed out in the meantime
+	if !sm.enterTarget(to, exec) {
+		return ErrTransitionTimeout
+	}
 
 	// check for entry actions, if there is one and it cannot be performed, roll back.
-	// otherwise continue
-	if entryAction := sm.entryActions[to]; entryAction != nil {
-		if err := entryAction(); err != nil {
-			sm.State = oldState
-			return fmt.Errorf("%w: %v", ErrEntryActionFailed, err)
+	// otherwise continue. entering a substate from outside its parents enters the parents
+	// first (outermost first)
+	entering := sm.entryChain(oldState, to)
+	if reentry != nil {
+		if err := sm.timed(to, reentry); err != nil {
+			return sm.rollback(oldState, exited, exec, err)
+		}
+	} else {
+		for _, s := range entering {
+			if err := sm.retryEntryAction(oldState, s, exec); err != nil {
+				return sm.rollback(oldState, exited, exec, err)
+			}
 		}
+
+		// post-entry actions only run once every entry action has succeeded, and roll back the same way
+		if err := sm.runPostEntryActions(entering); err != nil {
+			return sm.rollback(oldState, exited, exec, err)
+		}
+	}
+
+	// past this point the transition counts as done, so it can't be abandoned any more
+	if !sm.finishTransition(exec) {
+		return ErrTransitionTimeout
 	}
 
+	sm.recordHistory(oldState, to)
+	sm.recordVisits(entering)
+	sm.countStep()
+	sm.recordCoverage(matchedTransition)
+
+	// now that the state has been entered, start its timeout (if it has one)
+	sm.armTimeout(to)
+
 	return nil
 }
 
+// attempt a transition and report whether it succeeded. this is meant for hot loops that transition
+// speculatively and don't care why an attempt failed: a rejected transition (undefined, self-transition,
+// or failing guard) doesn't allocate an error unless a logger or recorder needs one. failures in actions are still
+// rolled back as usual, they just come back as false
+func (sm *StateMachine) TryTransition(to State) bool {
+	sm.beginTransition()
+	from := sm.current()
+	t, r := sm.lookup(from, to)
+	if r == accepted {
+		r = sm.check(t, from, nil)
+	}
+	if r != accepted {
+		sm.endTransition()
+		if sm.listening() {
+			sm.report(from, to, r.err(from, to))
+		} else {
+			sm.drainQueue()
+		}
+		return false
+	}
+
+	err := sm.execute(t, execution{})
+	sm.endTransition()
+	return sm.report(from, to, err) == nil
+}
+
+// like `Transition`, but rather than stopping at the first transition to the target, every candidate
+// transition to the target is tried in turn until one's guard passes. if none of them pass, the
+// returned error joins the reason each candidate was rejected, which helps when there are several
+// paths to the same target that are all failing for different reasons
+func (sm *StateMachine) TransitionAllOrReport(to State) error {
+	sm.beginTransition()
+	from := sm.current()
+	err := sm.transitionAllOrReport(from, to)
+	sm.endTransition()
+	return sm.report(from, to, err)
+}
+
+func (sm *StateMachine) transitionAllOrReport(from, to State) error {
+	var reasons []error
+	onlyGuards := true
+	for i, t := range sm.candidates(from) {
+		if !sm.sameState(t.To, to) {
+			continue
+		}
+		r, guardErr := sm.inspect(t, from, nil)
+		if r == accepted {
+			return sm.execute(t, execution{})
+		}
+
+		name := ""
+		if t.Name != "" {
+			name = fmt.Sprintf(" %q", t.Name)
+		}
+		if t.Event != "" {
+			name += fmt.Sprintf(" (event %q)", t.Event)
+		}
+		reason := r.reason()
+		if guardErr != nil {
+			reason += ": " + guardErr.Error()
+		}
+		if r != rejectGuard && r != rejectEntryGuard {
+			onlyGuards = false
+		}
+		reasons = append(reasons, fmt.Errorf("candidate %d%s from %s to %s: %s", i, name, stateName(t.From), stateName(t.To), reason))
+	}
+
+	if len(reasons) == 0 {
+		return &TransitionError{From: from, To: to, Reason: "no transition defined to this state"}
+	}
+	rejection := &TransitionError{From: from, To: to, Reason: "no candidate could be taken:\n" + errors.Join(reasons...).Error()}
+	if onlyGuards {
+		rejection.Err = ErrGuardFailed
+	}
+	return rejection
+}
+
+// register a hook that runs before every transition, once it has been matched and its guard has passed
+// but before any action has run. if the hook returns an error, the transition is abandoned and the error
+// is returned as-is. unlike a guard, the hook applies to every transition, which makes it a good fit for
+// cross-cutting policies like maintenance mode. hooks run in the order they were registered and the
+// first error stops the chain
+func (sm *StateMachine) BeforeTransition(hook func(from, to State) error) {
+	sm.beforeHooks = append(sm.beforeHooks, hook)
+}
+
+// register a hook that runs after the exit and transition actions have succeeded but immediately before
+// the current state is changed. returning an error aborts the transition: the state is left as it was
+// and the entry action is not run. passing nil removes the hook
+func (sm *StateMachine) SetOnBeforeCommit(hook func(from, to State) error) {
+	sm.beforeCommit = hook
+}
+
 // Set or replace the entry action for a given state. The entry action is a generic function that
 // you will define in your implementation. This is called during the transition following the state machine
-// transitioning from the present to the destination state
-func (sm *StateMachine) SetEntryAction(state State, action Action) {
-	sm.entryActions[state] = action
+// transitioning from the present to the destination state. The machine is returned so calls can be chained
+func (sm *StateMachine) SetEntryAction(state State, action Action) *StateMachine {
+	sm.entryActions[sm.key(state)] = action
+	delete(sm.debounces, sm.key(state))
+	return sm
+}
+
+// Set or replace the post-entry action for a given state. A post-entry action runs only after all of the
+// entry logic for a transition has completed, and a failing post-entry action rolls the transition back
+// just like a failing entry action. The full order of a transition is:
+// exit -> transition action -> entry -> post-entry
+func (sm *StateMachine) SetPostEntryAction(state State, action Action) {
+	sm.postEntryActions[sm.key(state)] = action
 }
 
 // Set or replace the exit action for a given state. The exit action is a generic function that
 // you will define in your implementation. This is called during the transition prior to the state machine
-// transitioning from the present to the destination state
-func (sm *StateMachine) SetExitAction(state State, action Action) {
-	sm.exitActions[state] = action
+// transitioning from the present to the destination state. The machine is returned so calls can be chained
+func (sm *StateMachine) SetExitAction(state State, action Action) *StateMachine {
+	sm.exitActions[sm.key(state)] = action
+	return sm
 }
 
+// put the machine back in its initial state. this is a jump rather than a transition: no guards, hooks
+// or actions run, and the initial state's timeout isn't started. any timeout pending for the state being
+// left is cancelled so it can't fire afterwards, and the transition budget set with SetMaxTransitions
+// starts over. the history, visit counts and coverage are left intact - use ResetAll to clear them too
 func (sm *StateMachine) Reset() {
-	sm.State = sm.InitialState
+	sm.cancelTimeout()
+	sm.setState(sm.InitialState)
+	sm.resetSteps()
+}
+
+// like Reset, but also clears the history, coverage and visit counts, leaving the machine as it was
+// when it was created: the initial state counts as visited once, and coverage (if enabled) starts over
+func (sm *StateMachine) ResetAll() {
+	sm.Reset()
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.history = nil
+	sm.visits = map[State]int{sm.key(sm.InitialState): 1}
+	if sm.coverage != nil {
+		sm.coverage = map[[2]State]bool{}
+	}
+}
+
+// reset the machine to its initial state and run the initial state's entry action, just as if it had
+// been entered through a transition. if the entry action fails, the machine stays where it was and
+// the error is returned. no exit action is run for the state being left. on success any pending
+// timeout is replaced by the initial state's own, as with a transition
+func (sm *StateMachine) ResetWithEntry() error {
+	sm.beginTransition()
+	defer sm.drainQueue()
+	defer sm.endTransition()
+
+	oldState := sm.current()
+	sm.setState(sm.InitialState)
+
+	if err := validateEntry(sm.InitialState); err != nil {
+		return sm.rollback(oldState, nil, execution{}, err)
+	}
+	if err := sm.runEntryAction(sm.InitialState); err != nil {
+		return sm.rollback(oldState, nil, execution{}, err)
+	}
+	if err := sm.runPostEntryActions([]State{sm.InitialState}); err != nil {
+		return sm.rollback(oldState, nil, execution{}, err)
+	}
+
+	sm.recordVisits([]State{sm.InitialState})
+	sm.resetSteps()
+	sm.armTimeout(sm.InitialState)
+	return nil
+}
+
+// run the post-entry actions of the given states in order, stopping at the first failure
+func (sm *StateMachine) runPostEntryActions(states []State) error {
+	for _, s := range states {
+		if action := sm.postEntryActions[sm.key(s)]; action != nil {
+			if err := safely(action); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// run the entry action registered for a state, if there is one, skipping it when the state is
+// debounced and the action already ran within its window
+func (sm *StateMachine) runEntryAction(state State) error {
+	k := sm.key(state)
+	entryAction := sm.entryActions[k]
+	if entryAction == nil {
+		return nil
+	}
+
+	window, debounced := sm.debounces[k]
+	if !debounced {
+		return sm.timed(state, entryAction)
+	}
+
+	now := sm.clock.Now()
+	sm.mu.Lock()
+	last, ran := sm.lastEntryRun[k]
+	sm.mu.Unlock()
+	if ran && now.Sub(last) < window {
+		return nil
+	}
+
+	if err := sm.timed(state, entryAction); err != nil {
+		return err
+	}
+
+	sm.mu.Lock()
+	sm.lastEntryRun[k] = now
+	sm.mu.Unlock()
+	return nil
+}
+
+// return the state the machine is currently in. prefer this to reading the `State` field, which races
+// with transitions happening on other goroutines (including timeouts firing in the background)
+func (sm *StateMachine) CurrentState() State {
+	return sm.current()
+}
+
+// read the current state under the lock, so that reads don't race with a timeout firing in the background
+func (sm *StateMachine) current() State {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.State
+}
+
+func (sm *StateMachine) setState(s State) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.State = s
+}
+
+// what a transition's guards are evaluated against, beyond the states involved
+type guardInput struct {
+	payload any           // the payload the transition was triggered with
+	machine *StateMachine // the machine whose history GuardHist guards are given
+	locked  bool          // whether the caller already holds the machine's lock
+	cache   guardCache    // the results of plain guards evaluated earlier in the same call, nil if not caching
+}
+
+// the history for GuardHist guards, only read when one needs it
+func (in guardInput) history() []HistoryEntry {
+	if in.locked {
+		return in.machine.copyHistory()
+	}
+	return in.machine.History()
+}
+
+// report whether every guard attached to the transition is satisfied when leaving `from`. a
+// transition without guards is always allowed, and a guard returning an error counts as unsatisfied
+func (t Transition) guardPasses(from State, in guardInput) bool {
+	ok, _ := t.evaluate(from, in)
+	return ok
+}
+
+// evaluate every guard attached to the transition, stopping at the first one that isn't satisfied.
+// the error is only set when a GuardErr guard returned one
+func (t Transition) evaluate(from State, in guardInput) (bool, error) {
+	if t.Guard != nil && !in.cache.passes(t.Guard) {
+		return false, nil
+	}
+	for _, guard := range t.Guards {
+		if guard != nil && !in.cache.passes(guard) {
+			return false, nil
+		}
+	}
+	if t.PayloadGuard != nil && !t.PayloadGuard(in.payload) {
+		return false, nil
+	}
+	if t.GuardFull != nil && !t.GuardFull(from, t.To) {
+		return false, nil
+	}
+	if t.GuardErr != nil {
+		if ok, err := t.GuardErr(); err != nil || !ok {
+			return false, err
+		}
+	}
+	if t.GuardHist != nil && !t.GuardHist(in.history()) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// run the transition's own action(s), if any, stopping at the first error
+func (t Transition) runAction(exec execution) error {
+	if t.Action != nil {
+		if err := t.Action(); err != nil {
+			return err
+		}
+	}
+	if t.PayloadAction != nil {
+		if err := t.PayloadAction(exec.payload); err != nil {
+			return err
+		}
+	}
+	if t.ResultAction != nil {
+		result, err := t.ResultAction()
+		if err != nil {
+			return err
+		}
+		if exec.result != nil {
+			*exec.result = result
+		}
+	}
+	return nil
+}
+
+// report whether any kind of action is attached to the transition
+func (t Transition) hasAction() bool {
+	return t.Action != nil || t.PayloadAction != nil || t.ResultAction != nil
+}
+
+// report whether the transition is a bare edge, with no guards, actions, or event attached
+func (t Transition) isPlain() bool {
+	return !t.guarded() && !t.hasAction() && t.Event == ""
+}
+
+// report whether any kind of guard is attached to the transition
+func (t Transition) guarded() bool {
+	return t.Guard != nil || len(t.Guards) > 0 || t.PayloadGuard != nil || t.GuardFull != nil ||
+		t.GuardErr != nil || t.GuardHist != nil
+}
+
+// run an action, converting a panic into an ordinary error that carries the panic value. this lets
+// the transition roll back as it would for any other failure instead of crashing the program
+func safely(action Action) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("action panicked: %v", r)
+		}
+	}()
+	return action()
+}
+
+// copy a transition along with the slices it holds, so the copy can be changed freely
+func (t Transition) deepCopy() Transition {
+	t.Guards = append([]Guard(nil), t.Guards...)
+	t.Metadata = maps.Clone(t.Metadata)
+	return t
+}
+
+// copy a list of transitions, see deepCopy
+func copyTransitions(transitions []Transition) []Transition {
+	copied := make([]Transition, len(transitions))
+	for i, t := range transitions {
+		copied[i] = t.deepCopy()
+	}
+	return copied
+}
+
+// report whether taking the transition from the given state would be a self-transition that wasn't
+// explicitly allowed
+func (sm *StateMachine) selfRejected(t Transition, from State) bool {
+	return !t.AllowSelf && sm.sameState(t.To, from)
 }
@@ -0,0 +1,666 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+// an action that appends its name to a shared log, so tests can check what ran and in which order
+func logged(log *[]string, name string) statemachine.Action {
+	return func() error {
+		*log = append(*log, name)
+		return nil
+	}
+}
+
+func failing(msg string) statemachine.Action {
+	return func() error { return errors.New(msg) }
+}
+
+func TestTransition(t *testing.T) {
+	pass := func() bool { return true }
+	fail := func() bool { return false }
+
+	tests := []struct {
+		name    string
+		setup   func(sm *statemachine.StateMachine)
+		to      statemachine.State
+		wantErr error
+		want    statemachine.State
+	}{
+		{
+			name:  "simple transition",
+			setup: func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Idle", "Running") },
+			to:    "Running",
+			want:  "Running",
+		},
+		{
+			name:    "undefined transition",
+			setup:   func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Idle", "Running") },
+			to:      "Stopped",
+			wantErr: statemachine.ErrInvalidTransition,
+			want:    "Idle",
+		},
+		{
+			name:    "no transitions out of the state",
+			setup:   func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Running", "Stopped") },
+			to:      "Stopped",
+			wantErr: statemachine.ErrInvalidTransition,
+			want:    "Idle",
+		},
+		{
+			name:    "failing guard",
+			setup:   func(sm *statemachine.StateMachine) { sm.AddTransition("Idle", "Running", fail, nil) },
+			to:      "Running",
+			wantErr: statemachine.ErrGuardFailed,
+			want:    "Idle",
+		},
+		{
+			name:  "every guard passes",
+			setup: func(sm *statemachine.StateMachine) { sm.AddGuardedTransition("Idle", "Running", pass, pass) },
+			to:    "Running",
+			want:  "Running",
+		},
+		{
+			name:    "one of several guards fails",
+			setup:   func(sm *statemachine.StateMachine) { sm.AddGuardedTransition("Idle", "Running", pass, fail) },
+			to:      "Running",
+			wantErr: statemachine.ErrGuardFailed,
+			want:    "Idle",
+		},
+		{
+			name: "guard told the states involved",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddTransitionFull("Idle", "Running", func(from, to statemachine.State) bool {
+					return from == "Idle" && to == "Running"
+				}, nil)
+			},
+			to:   "Running",
+			want: "Running",
+		},
+		{
+			name:    "self-transition",
+			setup:   func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Idle", "Idle") },
+			to:      "Idle",
+			wantErr: statemachine.ErrSelfTransition,
+			want:    "Idle",
+		},
+		{
+			name:  "allowed self-transition",
+			setup: func(sm *statemachine.StateMachine) { sm.AddSelfTransition("Idle", nil, nil) },
+			to:    "Idle",
+			want:  "Idle",
+		},
+		{
+			name: "failing exit action",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Idle", "Running")
+				sm.SetExitAction("Idle", failing("busy"))
+			},
+			to:      "Running",
+			wantErr: statemachine.ErrExitActionFailed,
+			want:    "Idle",
+		},
+		{
+			name: "failing entry action rolls back",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Idle", "Running")
+				sm.SetEntryAction("Running", failing("no power"))
+			},
+			to:      "Running",
+			wantErr: statemachine.ErrEntryActionFailed,
+			want:    "Idle",
+		},
+		{
+			name: "failing post-entry action rolls back",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Idle", "Running")
+				sm.SetPostEntryAction("Running", failing("no power"))
+			},
+			to:      "Running",
+			wantErr: statemachine.ErrEntryActionFailed,
+			want:    "Idle",
+		},
+		{
+			name: "panicking entry action rolls back",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Idle", "Running")
+				sm.SetEntryAction("Running", func() error { panic("boom") })
+			},
+			to:      "Running",
+			wantErr: statemachine.ErrEntryActionFailed,
+			want:    "Idle",
+		},
+		{
+			name: "before-transition hook vetoes",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Idle", "Running")
+				sm.BeforeTransition(func(from, to statemachine.State) error { return errMaintenance })
+			},
+			to:      "Running",
+			wantErr: errMaintenance,
+			want:    "Idle",
+		},
+		{
+			name: "before-commit hook vetoes",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Idle", "Running")
+				sm.SetOnBeforeCommit(func(from, to statemachine.State) error { return errMaintenance })
+			},
+			to:      "Running",
+			wantErr: errMaintenance,
+			want:    "Idle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Idle")
+			tt.setup(sm)
+
+			err := sm.Transition(tt.to)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Transition(%v): %v", tt.to, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition(%v) error = %v, want %v", tt.to, err, tt.wantErr)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+var errMaintenance = errors.New("down for maintenance")
+
+func TestTransitionOrder(t *testing.T) {
+	var log []string
+	sm := statemachine.NewStateMachine("Idle")
+	sm.AddTransition("Idle", "Running", nil, logged(&log, "action"))
+	sm.SetExitAction("Idle", logged(&log, "exit"))
+	sm.SetEntryAction("Running", logged(&log, "entry"))
+	sm.SetPostEntryAction("Running", logged(&log, "post-entry"))
+	sm.BeforeTransition(func(from, to statemachine.State) error {
+		log = append(log, "before")
+		return nil
+	})
+	sm.SetOnBeforeCommit(func(from, to statemachine.State) error {
+		log = append(log, "before-commit")
+		return nil
+	})
+
+	if err := sm.Transition("Running"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"before", "exit", "action", "before-commit", "entry", "post-entry"}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("ran %v, want %v", log, want)
+	}
+}
+
+func TestTransitionError(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(sm *statemachine.StateMachine)
+		wantErr    error
+		wantReason string
+	}{
+		{
+			name:       "missing transition",
+			setup:      func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Idle", "Stopped") },
+			wantErr:    statemachine.ErrInvalidTransition,
+			wantReason: "no transition defined to this state",
+		},
+		{
+			name: "failing guard",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddTransition("Idle", "Running", func() bool { return false }, nil)
+			},
+			wantErr:    statemachine.ErrGuardFailed,
+			wantReason: "the transition's guard was not satisfied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Idle")
+			tt.setup(sm)
+
+			err := sm.Transition("Running")
+			var te *statemachine.TransitionError
+			if !errors.As(err, &te) {
+				t.Fatalf("error %v isn't a *TransitionError", err)
+			}
+			if te.From != "Idle" || te.To != "Running" || te.Reason != tt.wantReason {
+				t.Fatalf("got %+v, want Idle -> Running: %s", te, tt.wantReason)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGuardErr(t *testing.T) {
+	errOffline := errors.New("payment provider offline")
+
+	tests := []struct {
+		name     string
+		guard    statemachine.GuardErr
+		wantErrs []error
+	}{
+		{name: "passes", guard: func() (bool, error) { return true, nil }},
+		{name: "fails", guard: func() (bool, error) { return false, nil }, wantErrs: []error{statemachine.ErrGuardFailed}},
+		{name: "errors", guard: func() (bool, error) { return false, errOffline }, wantErrs: []error{statemachine.ErrGuardFailed, errOffline}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Cart")
+			sm.AddTransitionGuardErr("Cart", "Paid", tt.guard, nil)
+
+			err := sm.Transition("Paid")
+			if len(tt.wantErrs) == 0 && err != nil {
+				t.Fatalf("Transition: %v", err)
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("error %v doesn't wrap %v", err, want)
+				}
+			}
+			if err == nil {
+				return
+			}
+
+			var te *statemachine.TransitionError
+			if !errors.As(err, &te) {
+				t.Fatalf("error %v isn't a *TransitionError", err)
+			}
+			if te.From != "Cart" || te.To != "Paid" {
+				t.Errorf("TransitionError from %v to %v, want from Cart to Paid", te.From, te.To)
+			}
+			if !errors.Is(te.Err, statemachine.ErrGuardFailed) {
+				t.Errorf("TransitionError.Err = %v, want ErrGuardFailed", te.Err)
+			}
+			if len(tt.wantErrs) > 1 && te.Cause != tt.wantErrs[1] {
+				t.Errorf("TransitionError.Cause = %v, want %v", te.Cause, tt.wantErrs[1])
+			}
+		})
+	}
+}
+
+func TestTransitionWith(t *testing.T) {
+	var got any
+	sm := statemachine.NewStateMachine("Cart")
+	sm.AddTransitionWithPayload("Cart", "Paid",
+		func(payload any) bool {
+			amount, ok := payload.(int)
+			return ok && amount > 0
+		},
+		func(payload any) error {
+			got = payload
+			return nil
+		})
+
+	if err := sm.TransitionWith("Paid", 0); !errors.Is(err, statemachine.ErrGuardFailed) {
+		t.Fatalf("TransitionWith(0) error = %v, want ErrGuardFailed", err)
+	}
+	if err := sm.Transition("Paid"); !errors.Is(err, statemachine.ErrGuardFailed) {
+		t.Fatalf("Transition without a payload error = %v, want ErrGuardFailed", err)
+	}
+	if err := sm.TransitionWith("Paid", 42); err != nil {
+		t.Fatal(err)
+	}
+	if got != 42 {
+		t.Fatalf("action received %v, want 42", got)
+	}
+}
+
+func TestTransitionResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(sm *statemachine.StateMachine)
+		want    any
+		wantErr bool
+	}{
+		{
+			name: "returns the action's value",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddTransitionR("Cart", "Paid", func() (any, error) { return "receipt-1", nil })
+			},
+			want: "receipt-1",
+		},
+		{
+			name:  "nil without a result action",
+			setup: func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Cart", "Paid") },
+		},
+		{
+			name: "discarded when the entry action fails",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddTransitionR("Cart", "Paid", func() (any, error) { return "receipt-1", nil })
+				sm.SetEntryAction("Paid", failing("ledger unavailable"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Cart")
+			tt.setup(sm)
+
+			got, err := sm.TransitionResult("Paid")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TransitionResult error = %v, want error: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddTransitionReplacesDuplicates(t *testing.T) {
+	sm := statemachine.NewStateMachine("Idle")
+	sm.AddTransition("Idle", "Running", func() bool { return false }, nil)
+	sm.AddTransition("Idle", "Running", func() bool { return true }, nil)
+
+	if n := len(sm.Transitions["Idle"]); n != 1 {
+		t.Fatalf("%d transitions registered, want 1", n)
+	}
+	if err := sm.Transition("Running"); err != nil {
+		t.Fatalf("the later guard didn't win: %v", err)
+	}
+}
+
+func TestBulkRegistration(t *testing.T) {
+	sm := statemachine.NewStateMachine("Idle")
+	sm.AddTransitions("Idle", "Running", "Stopped")
+	sm.AddTransitions("Running", "Stopped")
+	sm.AddBidirectional("Stopped", "Archived")
+
+	want := map[statemachine.State][]statemachine.State{
+		"Idle":     {"Running", "Stopped"},
+		"Running":  {"Stopped"},
+		"Stopped":  {"Archived"},
+		"Archived": {"Stopped"},
+	}
+	for from, targets := range want {
+		var got []statemachine.State
+		for _, t := range sm.Transitions[from] {
+			got = append(got, t.To)
+		}
+		if !reflect.DeepEqual(got, targets) {
+			t.Fatalf("transitions from %v = %v, want %v", from, got, targets)
+		}
+	}
+}
+
+func TestChaining(t *testing.T) {
+	var log []string
+	sm := statemachine.NewStateMachine("Idle").
+		AddSimpleTransition("Idle", "Running").
+		AddTransition("Running", "Stopped", nil, nil).
+		SetEntryAction("Running", logged(&log, "entry")).
+		SetExitAction("Running", logged(&log, "exit"))
+
+	for _, to := range []statemachine.State{"Running", "Stopped"} {
+		if err := sm.Transition(to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"entry", "exit"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("ran %v, want %v", log, want)
+	}
+}
+
+func TestTransitionPriority(t *testing.T) {
+	sm := statemachine.NewStateMachine("Review")
+	sm.AddEventTransition("Review", "decide", "Rejected")
+	sm.AddEventTransition("Review", "decide", "Approved")
+	sm.AddTransitionWithPriority("Review", "Escalated", 10, func() bool { return true })
+
+	want := []statemachine.State{"Escalated", "Rejected", "Approved"}
+	if got := sm.AvailableTransitions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("AvailableTransitions = %v, want %v", got, want)
+	}
+}
+
+func TestRemoveTransition(t *testing.T) {
+	sm := statemachine.NewStateMachine("Idle")
+	sm.AddTransitions("Idle", "Running", "Stopped")
+
+	if !sm.RemoveTransition("Idle", "Running") {
+		t.Fatal("RemoveTransition reported nothing removed")
+	}
+	if sm.RemoveTransition("Idle", "Running") {
+		t.Fatal("RemoveTransition removed a transition twice")
+	}
+	if err := sm.Transition("Running"); !errors.Is(err, statemachine.ErrInvalidTransition) {
+		t.Fatalf("Transition to a removed target error = %v, want ErrInvalidTransition", err)
+	}
+
+	sm.RemoveTransition("Idle", "Stopped")
+	if _, ok := sm.Transitions["Idle"]; ok {
+		t.Fatal("removing the last transition left an empty entry behind")
+	}
+
+	sm.AddTransitions("Idle", "Running", "Stopped")
+	sm.ClearTransitions("Idle")
+	if _, ok := sm.Transitions["Idle"]; ok {
+		t.Fatal("ClearTransitions left transitions behind")
+	}
+}
+
+func TestCanTransitionReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(sm *statemachine.StateMachine)
+		to         statemachine.State
+		want       bool
+		wantReason string
+	}{
+		{
+			name:  "allowed",
+			setup: func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Idle", "Running") },
+			to:    "Running",
+			want:  true,
+		},
+		{
+			name:       "no transitions at all",
+			setup:      func(sm *statemachine.StateMachine) {},
+			to:         "Running",
+			wantReason: "no transitions defined from this state",
+		},
+		{
+			name:       "no transition to the target",
+			setup:      func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Idle", "Stopped") },
+			to:         "Running",
+			wantReason: "no transition defined to this state",
+		},
+		{
+			name:       "self-transition",
+			setup:      func(sm *statemachine.StateMachine) { sm.AddSimpleTransition("Idle", "Idle") },
+			to:         "Idle",
+			wantReason: "self-transition not allowed",
+		},
+		{
+			name: "failing guard",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddTransition("Idle", "Running", func() bool { return false }, nil)
+			},
+			to:         "Running",
+			wantReason: "the transition's guard was not satisfied",
+		},
+		{
+			name: "failing entry guard",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Idle", "Running")
+				sm.SetEntryGuard("Running", func() bool { return false })
+			},
+			to:         "Running",
+			wantReason: "the target state's entry guard was not satisfied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Idle")
+			tt.setup(sm)
+
+			ok, reason := sm.CanTransitionReason(tt.to)
+			if ok != tt.want || reason != tt.wantReason {
+				t.Fatalf("CanTransitionReason(%v) = %v, %q, want %v, %q", tt.to, ok, reason, tt.want, tt.wantReason)
+			}
+			if sm.CanTransition(tt.to) != tt.want {
+				t.Fatalf("CanTransition disagrees with CanTransitionReason")
+			}
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	open := true
+	sm := statemachine.NewStateMachine("Idle")
+	sm.AddTransition("Idle", "Running", func() bool { return open }, nil)
+
+	if sm.IsTerminal() {
+		t.Fatal("IsTerminal with an open transition")
+	}
+	open = false
+	if !sm.IsTerminal() {
+		t.Fatal("not IsTerminal with every guard failing")
+	}
+}
+
+func TestReset(t *testing.T) {
+	var log []string
+	sm := statemachine.NewStateMachine("Idle")
+	sm.AddSimpleTransition("Idle", "Running")
+	sm.SetEntryAction("Idle", logged(&log, "entry"))
+	sm.Transition("Running")
+
+	sm.Reset()
+	if sm.CurrentState() != "Idle" || len(log) != 0 {
+		t.Fatalf("Reset: state %v, ran %v, want Idle and nothing run", sm.CurrentState(), log)
+	}
+	if len(sm.History()) != 1 {
+		t.Fatal("Reset cleared the history")
+	}
+
+	sm.Transition("Running")
+	if err := sm.ResetWithEntry(); err != nil {
+		t.Fatal(err)
+	}
+	if sm.CurrentState() != "Idle" || !reflect.DeepEqual(log, []string{"entry"}) {
+		t.Fatalf("ResetWithEntry: state %v, ran %v, want Idle and the entry action", sm.CurrentState(), log)
+	}
+
+	sm.ResetAll()
+	if len(sm.History()) != 0 || sm.VisitCount("Running") != 0 {
+		t.Fatal("ResetAll left the history or visit counts behind")
+	}
+}
+
+func TestResetWithEntryFailure(t *testing.T) {
+	sm := statemachine.NewStateMachine("Idle")
+	sm.AddSimpleTransition("Idle", "Running")
+	sm.Transition("Running")
+	sm.SetEntryAction("Idle", failing("not ready"))
+
+	if err := sm.ResetWithEntry(); !errors.Is(err, statemachine.ErrEntryActionFailed) {
+		t.Fatalf("ResetWithEntry error = %v, want ErrEntryActionFailed", err)
+	}
+	if got := sm.CurrentState(); got != "Running" {
+		t.Fatalf("state = %v, want Running", got)
+	}
+}
+
+// CurrentState is safe to call while other goroutines (and timeouts firing in the background) move
+// the machine. this is mostly a check for the race detector: run the tests with -race
+func TestCurrentStateDuringTransitions(t *testing.T) {
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddBidirectional("Off", "On")
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				sm.TryTransition("On")
+				sm.TryTransition("Off")
+			}
+		}
+	}()
+
+	// keep reading until the machine has gone back and forth plenty of times
+	for len(sm.History()) < 200 {
+		if got := sm.CurrentState(); got != "On" && got != "Off" {
+			t.Fatalf("CurrentState = %v, want On or Off", got)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+// callbacks can't call Transition on the machine running them, but can queue the next transition or
+// start it from another goroutine, which waits for the transition in progress to finish
+func TestTransitionFromCallbacks(t *testing.T) {
+	tests := []struct {
+		name string
+		next func(sm *statemachine.StateMachine)
+	}{
+		{name: "queued", next: func(sm *statemachine.StateMachine) { sm.QueueTransition("C") }},
+		{name: "from another goroutine", next: func(sm *statemachine.StateMachine) { go sm.Transition("C") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("A")
+			sm.AddSimpleTransition("A", "B").AddSimpleTransition("B", "C")
+			sm.SetEntryAction("B", func() error {
+				tt.next(sm)
+				return nil
+			})
+
+			if err := sm.Transition("B"); err != nil {
+				t.Fatal(err)
+			}
+			waitForState(t, sm, "C")
+		})
+	}
+}
+
+type color int
+
+func (c color) String() string { return [...]string{"Red", "Green"}[c] }
+
+func TestStatesFormattedWithStringer(t *testing.T) {
+	sm := statemachine.NewStateMachine(color(0))
+
+	err := sm.Transition(color(1))
+	if err == nil || !strings.Contains(err.Error(), "from Red to Green") {
+		t.Fatalf("error = %v, want it to name the states Red and Green", err)
+	}
+}
+
+// report whether s contains every one of the given substrings
+func containsAll(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if !strings.Contains(s, sub) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+// Package statemachinetest provides helpers for testing code built on statemachine.
+package statemachinetest
+
+import (
+	"sync"
+	"time"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+var _ statemachine.Clock = (*FakeClock)(nil)
+
+// FakeClock is a statemachine.Clock that only moves when told to, so timeouts, backoffs and
+// timestamps can be tested deterministically without sleeping:
+//
+//	clock := statemachinetest.NewFakeClock(time.Now())
+//	sm.SetClock(clock)
+//	sm.Transition(Pending)
+//	clock.Advance(30 * time.Minute) // fires Pending's timeout
+//
+// timeouts fire on a background goroutine, so wait for the outcome (e.g. with WaitForState) rather
+// than checking straight after Advance returns.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+// a channel returned by After, waiting for the clock to reach its deadline
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// create a fake clock set to the given time
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// the clock's current time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// return a channel that receives the clock's time once it has been advanced by at least `d`. a
+// duration of zero or less fires straight away, like time.After
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// move the clock forward by `d`, firing every channel from After whose deadline has been reached
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// report how many channels from After are still waiting, e.g. to check a timeout has been armed
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
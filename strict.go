@@ -0,0 +1,35 @@
+package statemachine
+
+import "fmt"
+
+// declare a state up front. registered states count as part of the machine even before any
+// transition refers to them, and in strict mode they're the only states transitions may use
+func (sm *StateMachine) RegisterState(s State) {
+	sm.remember(s)
+	sm.registered[sm.key(s)] = true
+}
+
+// turn strict mode on or off. in strict mode, adding a transition whose source or target hasn't
+// been declared with RegisterState panics with an error wrapping ErrUnknownState, so a typo in a
+// state name is caught while the machine is being set up rather than creating a state nobody can
+// leave. the initial state and AnyState never need registering. strict mode is off by default
+func (sm *StateMachine) StrictStates(strict bool) {
+	sm.strict = strict
+}
+
+// panic if strict mode is on and the transition refers to a state that wasn't registered
+func (sm *StateMachine) checkRegistered(t Transition) {
+	if !sm.strict {
+		return
+	}
+	for _, s := range []State{t.From, t.To} {
+		if !sm.usable(s) {
+			panic(fmt.Errorf("%w: %s is not registered (transition from %s to %s)", ErrUnknownState, stateName(s), stateName(t.From), stateName(t.To)))
+		}
+	}
+}
+
+// report whether strict mode lets transitions use the state
+func (sm *StateMachine) usable(s State) bool {
+	return !sm.strict || sm.sameState(s, AnyState) || sm.sameState(s, sm.InitialState) || sm.registered[sm.key(s)]
+}
@@ -0,0 +1,52 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestStrictStates(t *testing.T) {
+	tests := []struct {
+		name      string
+		from, to  statemachine.State
+		wantPanic bool
+	}{
+		{name: "registered states", from: "Paid", to: "Shipped"},
+		{name: "initial state", from: "Created", to: "Paid"},
+		{name: "any state", from: statemachine.AnyState, to: "Paid"},
+		{name: "unregistered target", from: "Paid", to: "Shiped", wantPanic: true},
+		{name: "unregistered source", from: "Payed", to: "Shipped", wantPanic: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Created")
+			sm.RegisterState("Paid")
+			sm.RegisterState("Shipped")
+			sm.StrictStates(true)
+
+			defer func() {
+				r := recover()
+				if (r != nil) != tt.wantPanic {
+					t.Fatalf("panic = %v, want panic %v", r, tt.wantPanic)
+				}
+				if err, _ := r.(error); r != nil && !errors.Is(err, statemachine.ErrUnknownState) {
+					t.Fatalf("panicked with %v, want ErrUnknownState", r)
+				}
+			}()
+			sm.AddSimpleTransition(tt.from, tt.to)
+		})
+	}
+}
+
+func TestRegisterStateWithoutStrictMode(t *testing.T) {
+	sm := statemachine.NewStateMachine("Created")
+	sm.RegisterState("Archived")
+	sm.AddSimpleTransition("Created", "Paid") // fine without strict mode
+
+	if err := sm.ForceState("Archived"); err != nil {
+		t.Fatalf("ForceState to a registered state = %v", err)
+	}
+}
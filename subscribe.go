@@ -0,0 +1,68 @@
+package statemachine
+
+import (
+	"sync"
+	"time"
+)
+
+// how many undelivered changes a subscriber's channel holds before further changes are dropped
+const subscriberBuffer = 16
+
+// StateChange describes a successful transition, as delivered to subscribers
+type StateChange struct {
+	From State
+	To   State
+	Time time.Time
+}
+
+// the channels currently subscribed to state changes. it has its own lock so that delivering a
+// change never has to wait on the state lock, and so a channel can't be closed mid-send
+type subscribers struct {
+	mu       sync.Mutex
+	channels []chan StateChange
+}
+
+// subscribe to state changes. the returned channel receives a StateChange for every successful
+// transition from now on. delivery never blocks the machine: each channel is buffered, and when a
+// subscriber falls so far behind that its buffer is full, further changes are dropped for that
+// subscriber until it catches up. subscriptions belong to this machine and are not carried over by Clone
+func (sm *StateMachine) Subscribe() <-chan StateChange {
+	ch := make(chan StateChange, subscriberBuffer)
+
+	sm.subs.mu.Lock()
+	defer sm.subs.mu.Unlock()
+	sm.subs.channels = append(sm.subs.channels, ch)
+	return ch
+}
+
+// stop delivering state changes to a channel returned by Subscribe, and close it so that a
+// subscriber ranging over it finishes. unsubscribing a channel more than once is a no-op
+func (sm *StateMachine) Unsubscribe(ch <-chan StateChange) {
+	sm.subs.mu.Lock()
+	defer sm.subs.mu.Unlock()
+
+	for i, c := range sm.subs.channels {
+		if c == ch {
+			sm.subs.channels = append(sm.subs.channels[:i], sm.subs.channels[i+1:]...)
+			close(c)
+			return
+		}
+	}
+}
+
+// deliver a state change to every subscriber without blocking
+func (sm *StateMachine) publish(from, to State) {
+	sm.subs.mu.Lock()
+	defer sm.subs.mu.Unlock()
+	if len(sm.subs.channels) == 0 {
+		return
+	}
+
+	change := StateChange{From: from, To: to, Time: sm.clock.Now()}
+	for _, c := range sm.subs.channels {
+		select {
+		case c <- change:
+		default:
+		}
+	}
+}
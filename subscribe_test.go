@@ -0,0 +1,78 @@
+package statemachine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestSubscribe(t *testing.T) {
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddBidirectional("Off", "On")
+	changes := sm.Subscribe()
+
+	sm.Transition("On")
+	sm.Transition("Broken")
+	sm.Transition("Off")
+
+	for _, want := range []statemachine.StateChange{{From: "Off", To: "On"}, {From: "On", To: "Off"}} {
+		got := <-changes
+		if got.From != want.From || got.To != want.To {
+			t.Fatalf("change = %v -> %v, want %v -> %v", got.From, got.To, want.From, want.To)
+		}
+	}
+
+	sm.Unsubscribe(changes)
+	sm.Unsubscribe(changes)
+	if _, open := <-changes; open {
+		t.Fatal("channel still open after Unsubscribe")
+	}
+}
+
+func TestSubscribeDoesNotBlock(t *testing.T) {
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddBidirectional("Off", "On")
+	sm.Subscribe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			sm.Transition("On")
+			sm.Transition("Off")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a subscriber that never reads blocked the machine")
+	}
+}
+
+func TestWaitForState(t *testing.T) {
+	sm := statemachine.NewStateMachine("Pending")
+	sm.AddSimpleTransition("Pending", "Done")
+
+	if err := sm.WaitForState(context.Background(), "Pending"); err != nil {
+		t.Fatalf("waiting for the current state: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := sm.WaitForState(ctx, "Done"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForState error = %v, want context.DeadlineExceeded", err)
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		sm.Transition("Done")
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := sm.WaitForState(ctx, "Done"); err != nil {
+		t.Fatalf("WaitForState: %v", err)
+	}
+}
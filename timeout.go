@@ -0,0 +1,112 @@
+package statemachine
+
+import "time"
+
+// an automatic transition to `to` once the machine has spent `after` in a state
+type timeout struct {
+	after time.Duration
+	to    State
+}
+
+// a timeout that has been armed for the state the machine is currently in. closing `cancel` stops
+// the background goroutine waiting on it
+type pendingTimeout struct {
+	state    State
+	deadline time.Time
+	cancel   chan struct{}
+}
+
+// have the machine automatically attempt a transition to `to` once it has spent `d` in `state`, e.g.
+// expiring a pending payment after 30 minutes. the timer starts whenever `state` is entered through a
+// transition and is cancelled if the machine leaves the state before it fires. the automatic transition
+// goes through `Transition` like any other, so it can still be rejected by a guard or failing action
+func (sm *StateMachine) SetTimeout(state State, d time.Duration, to State) {
+	sm.timeouts[sm.key(state)] = timeout{after: d, to: to}
+}
+
+// cancel whatever timeout is pending and, if the newly entered state has a timeout configured, start a
+// background goroutine waiting for it to expire
+func (sm *StateMachine) armTimeout(state State) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.stopPending()
+
+	t, ok := sm.timeouts[sm.key(state)]
+	if !ok {
+		return
+	}
+
+	pending := &pendingTimeout{
+		state:    state,
+		deadline: sm.clock.Now().Add(t.after),
+		cancel:   make(chan struct{}),
+	}
+	sm.pending = pending
+
+	// start the timer before handing it off, so a clock that is advanced by hand as soon as the
+	// transition returns already knows about it
+	expired := sm.clock.After(t.after)
+	go sm.awaitTimeout(pending, t, expired)
+}
+
+// cancel whatever timeout is pending, so it can't fire after the machine has been moved without a transition
+func (sm *StateMachine) cancelTimeout() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.stopPending()
+}
+
+// stop the pending timeout's goroutine, if there is one. the lock must be held
+func (sm *StateMachine) stopPending() {
+	if sm.pending != nil {
+		close(sm.pending.cancel)
+		sm.pending = nil
+	}
+}
+
+// wait for a pending timeout to expire and then perform its transition, unless it was cancelled first
+func (sm *StateMachine) awaitTimeout(pending *pendingTimeout, t timeout, expired <-chan time.Time) {
+	select {
+	case <-pending.cancel:
+		return
+	case <-expired:
+	}
+
+	// the timer may have expired at the same moment the machine moved on, so only fire if this is
+	// still the timeout armed for the current state. the check is made once the machine has been
+	// reserved for the transition, so nothing can move it in between
+	sm.beginTransition()
+	sm.mu.Lock()
+	from := sm.State
+	armed := sm.pending == pending
+	if armed {
+		sm.pending = nil
+	}
+	sm.mu.Unlock()
+	if !armed || !sm.sameState(from, pending.state) {
+		sm.endTransition()
+		sm.drainQueue()
+		return
+	}
+
+	to, err := sm.transitionWith(from, t.to, nil)
+	sm.endTransition()
+	_ = sm.report(from, to, err)
+}
+
+// report how long is left until the current state's timeout fires, e.g. for a countdown in a UI. the
+// second result is false when no timeout is pending for the current state. once the deadline has
+// passed but the automatic transition hasn't happened yet, the remaining time is zero
+func (sm *StateMachine) TimeoutRemaining() (time.Duration, bool) {
+	now := sm.clock.Now()
+
+	sm.mu.RLock()
+	pending, current := sm.pending, sm.State
+	sm.mu.RUnlock()
+
+	if pending == nil || !sm.sameState(pending.state, current) {
+		return 0, false
+	}
+	return max(pending.deadline.Sub(now), 0), true
+}
@@ -0,0 +1,157 @@
+package statemachine_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	statemachine "github.com/jwald3/lollipop"
+	"github.com/jwald3/lollipop/statemachinetest"
+)
+
+// wait for the machine to reach a state, failing the test if it takes too long
+func waitForState(t *testing.T, sm *statemachine.StateMachine, target statemachine.State) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := sm.WaitForState(ctx, target); err != nil {
+		t.Fatalf("waiting for %v: %v (in %v)", target, err, sm.CurrentState())
+	}
+}
+
+func TestTimeoutFiresWithFakeClock(t *testing.T) {
+	clock := statemachinetest.NewFakeClock(time.Unix(0, 0))
+	sm := statemachine.NewStateMachine("Idle")
+	sm.SetClock(clock)
+	sm.AddSimpleTransition("Idle", "Pending").AddSimpleTransition("Pending", "Expired")
+	sm.SetTimeout("Pending", 30*time.Minute, "Expired")
+
+	if err := sm.Transition("Pending"); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, ok := sm.TimeoutRemaining(); !ok || remaining != 30*time.Minute {
+		t.Fatalf("TimeoutRemaining = %v, %v, want 30m, true", remaining, ok)
+	}
+
+	clock.Advance(29 * time.Minute)
+	if got := sm.CurrentState(); got != "Pending" {
+		t.Fatalf("state before the deadline = %v, want Pending", got)
+	}
+
+	clock.Advance(time.Minute)
+	waitForState(t, sm, "Expired")
+}
+
+func TestTimeoutCancelledWhenLeavingState(t *testing.T) {
+	clock := statemachinetest.NewFakeClock(time.Unix(0, 0))
+	sm := statemachine.NewStateMachine("Idle")
+	sm.SetClock(clock)
+	sm.AddSimpleTransition("Idle", "Pending").AddSimpleTransition("Pending", "Paid").AddSimpleTransition("Pending", "Expired")
+	sm.SetTimeout("Pending", time.Minute, "Expired")
+
+	sm.Transition("Pending")
+	if err := sm.Transition("Paid"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+
+	if clock.Waiters() != 0 {
+		t.Fatalf("%d timers still waiting", clock.Waiters())
+	}
+	if _, ok := sm.TimeoutRemaining(); ok {
+		t.Fatal("timeout still pending after leaving the state")
+	}
+	if got := sm.CurrentState(); got != "Paid" {
+		t.Fatalf("state = %v, want Paid", got)
+	}
+}
+
+func TestResetCancelsTimeout(t *testing.T) {
+	clock := statemachinetest.NewFakeClock(time.Unix(0, 0))
+	sm := statemachine.NewStateMachine("Idle")
+	sm.SetClock(clock)
+	sm.AddSimpleTransition("Idle", "Pending").AddSimpleTransition("Pending", "Expired")
+	sm.SetTimeout("Pending", time.Minute, "Expired")
+
+	sm.Transition("Pending")
+	sm.Reset()
+	sm.ForceState("Pending")
+	clock.Advance(time.Hour)
+
+	// give a stray timer the chance to fire before checking it didn't
+	time.Sleep(20 * time.Millisecond)
+	if got := sm.CurrentState(); got != "Pending" {
+		t.Fatalf("state = %v, want Pending", got)
+	}
+}
+
+func TestTimeoutDoesNotRaceCallerTransition(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		clock := statemachinetest.NewFakeClock(time.Unix(0, 0))
+		sm := statemachine.NewStateMachine("Idle")
+		sm.SetClock(clock)
+		sm.AddSimpleTransition("Idle", "Pending").AddSimpleTransition("Pending", "Expired").AddSimpleTransition("Pending", "Paid")
+		sm.SetTimeout("Pending", time.Minute, "Expired")
+
+		var exits atomic.Int32
+		sm.SetExitAction("Pending", func() error {
+			exits.Add(1)
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+
+		sm.Transition("Pending")
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clock.Advance(time.Minute)
+		}()
+		sm.Transition("Paid")
+		wg.Wait()
+		time.Sleep(5 * time.Millisecond)
+
+		if n := exits.Load(); n != 1 {
+			t.Fatalf("Pending's exit action ran %d times, want 1", n)
+		}
+		if n := len(sm.History()); n != 2 {
+			t.Fatalf("history has %d entries, want 2: %v", n, sm.History())
+		}
+	}
+}
+
+func TestConcurrentTransitionsRunOneAtATime(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		sm := statemachine.NewStateMachine("A")
+		sm.AddSimpleTransition("A", "B").AddSimpleTransition("A", "C")
+
+		var exits atomic.Int32
+		sm.SetExitAction("A", func() error {
+			exits.Add(1)
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+
+		var wg sync.WaitGroup
+		var succeeded atomic.Int32
+		for _, to := range []statemachine.State{"B", "C"} {
+			wg.Add(1)
+			go func(to statemachine.State) {
+				defer wg.Done()
+				if sm.Transition(to) == nil {
+					succeeded.Add(1)
+				}
+			}(to)
+		}
+		wg.Wait()
+
+		if n := exits.Load(); n != 1 {
+			t.Fatalf("A's exit action ran %d times, want 1", n)
+		}
+		if n := succeeded.Load(); n != 1 {
+			t.Fatalf("%d transitions out of A succeeded, want 1", n)
+		}
+	}
+}
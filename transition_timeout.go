@@ -0,0 +1,96 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTransitionTimeout is returned when a transition's actions take longer than the machine's
+// transition timeout
+var ErrTransitionTimeout = errors.New("transition timed out")
+
+// the bookkeeping shared between a transition running under a timeout and the caller waiting on it.
+// both fields are guarded by the machine's mutex
+type deadline struct {
+	abandoned bool // the caller gave up waiting and rolled the state back
+	finished  bool // the transition got past its last action and can no longer be abandoned
+}
+
+// set a limit on how long a transition's actions may take, as a safety net against actions that hang.
+// when a transition runs longer than `d`, the caller stops waiting: the state is rolled back to where
+// it was and ErrTransitionTimeout is returned. a zero or negative duration removes the limit.
+//
+// Go can't stop a running function from the outside, so the abandoned actions carry on running in the
+// background until they return. once abandoned, the transition won't change the state, roll back
+// or record anything - even if one of its actions fails later - but whatever side effects its actions have still happen
+func (sm *StateMachine) SetTransitionTimeout(d time.Duration) {
+	sm.transitionTimeout = d
+}
+
+// carry out a transition's actions in the background, giving up on them after the transition timeout
+func (sm *StateMachine) executeWithin(t Transition, exec execution) error {
+	oldState := sm.current()
+	exec.deadline = &deadline{}
+
+	expired := sm.clock.After(sm.transitionTimeout)
+	done := make(chan error, 1)
+	go func() {
+		done <- sm.runTransition(t, exec)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-expired:
+	}
+
+	sm.mu.Lock()
+	if exec.deadline.finished {
+		// too late to abandon it, and it's about to return anyway
+		sm.mu.Unlock()
+		return <-done
+	}
+	exec.deadline.abandoned = true
+	sm.State = oldState
+	sm.mu.Unlock()
+
+	return fmt.Errorf("%w after %v: from %s to %s", ErrTransitionTimeout, sm.transitionTimeout, stateName(oldState), stateName(t.To))
+}
+
+// report whether the transition has been abandoned by its caller
+func (sm *StateMachine) abandoned(exec execution) bool {
+	if exec.deadline == nil {
+		return false
+	}
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return exec.deadline.abandoned
+}
+
+// move into the transition's target state, unless the transition has been abandoned
+func (sm *StateMachine) enterTarget(to State, exec execution) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if exec.deadline != nil && exec.deadline.abandoned {
+		return false
+	}
+	sm.State = to
+	return true
+}
+
+// mark the transition as past the point of being abandoned, unless it already has been
+func (sm *StateMachine) finishTransition(exec execution) bool {
+	if exec.deadline == nil {
+		return true
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if exec.deadline.abandoned {
+		return false
+	}
+	exec.deadline.finished = true
+	return true
+}
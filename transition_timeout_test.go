@@ -0,0 +1,85 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestTransitionTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		delay   time.Duration
+		wantErr error
+		want    statemachine.State
+	}{
+		{name: "fast action", delay: 0, wantErr: nil, want: "B"},
+		{name: "slow action", delay: 200 * time.Millisecond, wantErr: statemachine.ErrTransitionTimeout, want: "A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("A")
+			sm.AddTransition("A", "B", nil, func() error {
+				time.Sleep(tt.delay)
+				return nil
+			})
+			sm.SetTransitionTimeout(50 * time.Millisecond)
+
+			if err := sm.Transition("B"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition(B) = %v, want %v", err, tt.wantErr)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbandonedTransitionDoesNotRollBackLater(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	sm := statemachine.NewStateMachine("A")
+	sm.AddSimpleTransition("A", "B").AddSimpleTransition("A", "C")
+	sm.SetEntryAction("B", func() error {
+		<-release
+		return errors.New("entry failed")
+	})
+	compensated := false
+	sm.SetExitAction("A", func() error { return nil })
+	sm.SetExitCompensation("A", func() error {
+		compensated = true
+		return nil
+	})
+	sm.SetRollbackMode(statemachine.RollbackWithReentry)
+	sm.SetEntryAction("A", func() error {
+		close(done)
+		return nil
+	})
+	sm.SetTransitionTimeout(20 * time.Millisecond)
+
+	if err := sm.Transition("B"); !errors.Is(err, statemachine.ErrTransitionTimeout) {
+		t.Fatalf("Transition(B) = %v, want ErrTransitionTimeout", err)
+	}
+	if err := sm.Transition("C"); err != nil {
+		t.Fatalf("Transition(C) = %v", err)
+	}
+
+	// let the abandoned entry action fail and give its rollback time to (not) happen
+	close(release)
+	select {
+	case <-done:
+		t.Fatal("abandoned transition re-entered A")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if got := sm.CurrentState(); got != "C" {
+		t.Fatalf("state = %v, want C", got)
+	}
+	if compensated {
+		t.Fatal("abandoned transition ran A's exit compensation")
+	}
+}
@@ -0,0 +1,67 @@
+package statemachine_test
+
+import (
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+// a machine whose only way out of A is guarded by a guard that always fails
+func newGuardedMachine() *statemachine.StateMachine {
+	sm := statemachine.NewStateMachine("A")
+	sm.AddTransition("A", "B", func() bool { return false }, nil)
+	sm.AddSimpleTransition("A", "C").AddSimpleTransition("C", "A")
+	return sm
+}
+
+func TestTryTransition(t *testing.T) {
+	tests := []struct {
+		name string
+		to   statemachine.State
+		want bool
+		end  statemachine.State
+	}{
+		{name: "allowed", to: "C", want: true, end: "C"},
+		{name: "guard fails", to: "B", want: false, end: "A"},
+		{name: "undefined", to: "D", want: false, end: "A"},
+		{name: "self-transition", to: "A", want: false, end: "A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newGuardedMachine()
+			if got := sm.TryTransition(tt.to); got != tt.want {
+				t.Fatalf("TryTransition(%v) = %v, want %v", tt.to, got, tt.want)
+			}
+			if got := sm.CurrentState(); got != tt.end {
+				t.Fatalf("state = %v, want %v", got, tt.end)
+			}
+		})
+	}
+}
+
+func TestTryTransitionRejectionDoesNotAllocate(t *testing.T) {
+	sm := newGuardedMachine()
+	allocs := testing.AllocsPerRun(100, func() {
+		sm.TryTransition("B")
+	})
+	if allocs != 0 {
+		t.Fatalf("rejected TryTransition made %v allocations, want 0", allocs)
+	}
+}
+
+func BenchmarkTryTransitionRejected(b *testing.B) {
+	sm := newGuardedMachine()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sm.TryTransition("B")
+	}
+}
+
+func BenchmarkTransitionRejected(b *testing.B) {
+	sm := newGuardedMachine()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = sm.Transition("B")
+	}
+}
@@ -0,0 +1,57 @@
+package statemachine
+
+// TwoPhaseAction is a side effect that takes part in a transition as a single unit of work.
+// Prepare is called for every participant before any exit, transition, or entry action runs.
+// If all prepares succeed, the participants are committed once the transition completes.
+// If any prepare fails, or the transition fails later on, every participant is aborted instead.
+type TwoPhaseAction interface {
+	Prepare() error
+	Commit()
+	Abort()
+}
+
+// Set or replace the two-phase action for a given state. The action participates in any transition
+// that leaves or enters the state, giving all-or-nothing semantics across the exit and entry side
+// of the transition. Note that Abort may be called on an action whose own Prepare failed.
+func (sm *StateMachine) SetTwoPhaseAction(state State, action TwoPhaseAction) {
+	sm.twoPhaseActions[sm.key(state)] = action
+}
+
+// collect the two-phase actions involved in moving between two states. the source state's action
+// is prepared first, followed by the target's. a self-transition only includes its action once
+func (sm *StateMachine) twoPhaseParticipants(from, to State) []TwoPhaseAction {
+	var participants []TwoPhaseAction
+	if action := sm.twoPhaseActions[sm.key(from)]; action != nil {
+		participants = append(participants, action)
+	}
+	if !sm.sameState(from, to) {
+		if action := sm.twoPhaseActions[sm.key(to)]; action != nil {
+			participants = append(participants, action)
+		}
+	}
+	return participants
+}
+
+// prepare every participant in order, stopping at the first failure. when a prepare fails, all
+// participants are aborted (including those that were never asked to prepare) before returning
+func prepareAll(participants []TwoPhaseAction) error {
+	for _, p := range participants {
+		if err := p.Prepare(); err != nil {
+			abortAll(participants)
+			return err
+		}
+	}
+	return nil
+}
+
+func commitAll(participants []TwoPhaseAction) {
+	for _, p := range participants {
+		p.Commit()
+	}
+}
+
+func abortAll(participants []TwoPhaseAction) {
+	for _, p := range participants {
+		p.Abort()
+	}
+}
@@ -0,0 +1,79 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+// a two-phase participant that logs every call it receives
+type participant struct {
+	name       string
+	log        *[]string
+	prepareErr error
+}
+
+func (p *participant) Prepare() error {
+	*p.log = append(*p.log, "prepare:"+p.name)
+	return p.prepareErr
+}
+
+func (p *participant) Commit() { *p.log = append(*p.log, "commit:"+p.name) }
+func (p *participant) Abort()  { *p.log = append(*p.log, "abort:"+p.name) }
+
+func TestTwoPhaseAction(t *testing.T) {
+	errLocked := errors.New("row locked")
+
+	tests := []struct {
+		name       string
+		prepareErr error
+		entryErr   error
+		wantErr    error
+		want       statemachine.State
+		wantLog    []string
+	}{
+		{
+			name:    "commits once the transition succeeds",
+			want:    "Shipped",
+			wantLog: []string{"prepare:Paid", "prepare:Shipped", "commit:Paid", "commit:Shipped"},
+		},
+		{
+			name:       "aborts everyone when a prepare fails",
+			prepareErr: errLocked,
+			wantErr:    statemachine.ErrPrepareFailed,
+			want:       "Paid",
+			wantLog:    []string{"prepare:Paid", "prepare:Shipped", "abort:Paid", "abort:Shipped"},
+		},
+		{
+			name:     "aborts everyone when the entry action fails",
+			entryErr: errLocked,
+			wantErr:  statemachine.ErrEntryActionFailed,
+			want:     "Paid",
+			wantLog:  []string{"prepare:Paid", "prepare:Shipped", "abort:Paid", "abort:Shipped"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			sm := statemachine.NewStateMachine("Paid")
+			sm.AddSimpleTransition("Paid", "Shipped")
+			sm.SetTwoPhaseAction("Paid", &participant{name: "Paid", log: &log})
+			sm.SetTwoPhaseAction("Shipped", &participant{name: "Shipped", log: &log, prepareErr: tt.prepareErr})
+			sm.SetEntryAction("Shipped", func() error { return tt.entryErr })
+
+			err := sm.Transition("Shipped")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition error = %v, want %v", err, tt.wantErr)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(log, tt.wantLog) {
+				t.Fatalf("calls = %v, want %v", log, tt.wantLog)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNothingToUndo is returned by Undo when there's no transition to take back
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// take back the most recent transition, returning the machine to the state it came from. the current
+// state's exit action and the previous state's entry action are run, and the transition is popped off
+// the history, so repeated calls keep walking back through it.
+//
+// this is a pop off the history stack, not a transition through the graph: no guards are checked and
+// no edge back to the previous state needs to exist. an entry action failure rolls back like it would
+// for a transition, leaving the history as it was. ErrNothingToUndo is returned when the history is
+// empty or the machine has been moved with Reset since its last transition
+func (sm *StateMachine) Undo() error {
+	sm.beginTransition()
+	current := sm.current()
+
+	sm.mu.RLock()
+	n := len(sm.history)
+	var last HistoryEntry
+	if n > 0 {
+		last = sm.history[n-1]
+	}
+	sm.mu.RUnlock()
+
+	if n == 0 || !sm.sameState(last.To, current) {
+		sm.endTransition()
+		sm.drainQueue()
+		return fmt.Errorf("%w: in state %s", ErrNothingToUndo, stateName(current))
+	}
+	err := sm.undo(last)
+	sm.endTransition()
+	return sm.report(current, last.From, err)
+}
+
+// move back along a history entry, running exit and entry actions on the way
+func (sm *StateMachine) undo(last HistoryEntry) error {
+	var exited []State
+	for _, exiting := range sm.exitChain(last.To, last.From) {
+		if exitAction := sm.exitActions[sm.key(exiting)]; exitAction != nil {
+			if err := sm.timed(exiting, exitAction); err != nil {
+				return fmt.Errorf("%w: %v", ErrExitActionFailed, err)
+			}
+			exited = append(exited, exiting)
+		}
+	}
+
+	sm.setState(last.From)
+	for _, s := range sm.entryChain(last.To, last.From) {
+		if err := sm.enter(last.To, s); err != nil {
+			return sm.rollback(last.To, exited, execution{}, err)
+		}
+	}
+
+	sm.mu.Lock()
+	sm.history = sm.history[:len(sm.history)-1]
+	sm.mu.Unlock()
+
+	sm.armTimeout(last.From)
+	return nil
+}
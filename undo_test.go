@@ -0,0 +1,77 @@
+package statemachine_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestUndo(t *testing.T) {
+	var log []string
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddTransitions("Draft", "Review")
+	sm.AddTransitions("Review", "Published")
+	sm.SetEntryAction("Draft", logged(&log, "enter Draft"))
+	sm.SetExitAction("Review", logged(&log, "exit Review"))
+
+	if err := sm.Transition("Review"); err != nil {
+		t.Fatal(err)
+	}
+	log = nil
+	if err := sm.Undo(); err != nil {
+		t.Fatalf("Undo returned %v", err)
+	}
+	if got := sm.CurrentState(); got != "Draft" {
+		t.Fatalf("state after Undo = %v, want Draft", got)
+	}
+	if want := []string{"exit Review", "enter Draft"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("actions = %v, want %v", log, want)
+	}
+	if n := len(sm.History()); n != 0 {
+		t.Fatalf("history has %d entries after Undo, want 0", n)
+	}
+	if err := sm.Undo(); !errors.Is(err, statemachine.ErrNothingToUndo) {
+		t.Fatalf("second Undo = %v, want ErrNothingToUndo", err)
+	}
+}
+
+func TestUndoAfterForceState(t *testing.T) {
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddTransitions("Draft", "Review")
+	sm.AddTransitions("Review", "Published")
+
+	if err := sm.Transition("Review"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.ForceState("Published"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Undo(); err != nil {
+		t.Fatalf("Undo returned %v", err)
+	}
+	if got := sm.CurrentState(); got != "Review" {
+		t.Fatalf("state after Undo = %v, want Review", got)
+	}
+}
+
+func TestUndoEntryFailure(t *testing.T) {
+	sm := statemachine.NewStateMachine("Draft")
+	sm.AddTransitions("Draft", "Review")
+
+	if err := sm.Transition("Review"); err != nil {
+		t.Fatal(err)
+	}
+	sm.SetEntryAction("Draft", failing("locked"))
+
+	if err := sm.Undo(); !errors.Is(err, statemachine.ErrEntryActionFailed) {
+		t.Fatalf("Undo = %v, want ErrEntryActionFailed", err)
+	}
+	if got := sm.CurrentState(); got != "Review" {
+		t.Fatalf("state after failed Undo = %v, want Review", got)
+	}
+	if n := len(sm.History()); n != 1 {
+		t.Fatalf("history has %d entries after failed Undo, want 1", n)
+	}
+}
@@ -0,0 +1,111 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TypeConsistency checks that every state the machine refers to has the same concrete type. since
+// states are untyped, mixing e.g. "On" and LightState("On") compiles fine, but the two never compare
+// equal, so transitions silently fail to match. the returned error lists each type that was found
+// along with an example state of that type
+func (sm *StateMachine) TypeConsistency() error {
+	var types []string
+	examples := map[string]State{}
+	for _, s := range sm.allStates() {
+		name := fmt.Sprintf("%T", s)
+		if _, seen := examples[name]; !seen {
+			examples[name] = s
+			types = append(types, name)
+		}
+	}
+
+	if len(types) <= 1 {
+		return nil
+	}
+
+	described := make([]string, len(types))
+	for i, name := range types {
+		described[i] = fmt.Sprintf("%s (e.g. %s)", name, stateName(examples[name]))
+	}
+	return fmt.Errorf("%w: states have mixed types: %s", ErrInvalidDefinition, strings.Join(described, ", "))
+}
+
+// Validate looks for common configuration mistakes before a machine is put into service, returning
+// nil for a well-formed machine or a joined error with one entry per problem found:
+//   - entry or exit actions registered for a state that no transition refers to
+//   - a transition target that has no way out and no actions of its own, which is often a typo
+//   - an initial state with no outgoing transitions
+//   - a state marked with RequireNonTerminal that has no outgoing transitions
+func (sm *StateMachine) Validate() error {
+	referenced := map[State]bool{}
+	for from, transitions := range sm.Transitions {
+		if from != AnyState && len(transitions) > 0 {
+			referenced[from] = true
+		}
+		for _, t := range transitions {
+			referenced[sm.key(t.To)] = true
+		}
+	}
+
+	var problems []error
+	for _, registry := range []struct {
+		kind    string
+		actions map[State]Action
+	}{
+		{"entry", sm.entryActions},
+		{"post-entry", sm.postEntryActions},
+		{"exit", sm.exitActions},
+	} {
+		registered := make(map[State]bool, len(registry.actions))
+		for s := range registry.actions {
+			registered[s] = true
+		}
+		for _, s := range sm.inOrder(registered) {
+			if !referenced[sm.key(s)] {
+				problems = append(problems, fmt.Errorf("%w: %s action registered for %s, which no transition refers to", ErrInvalidDefinition, registry.kind, stateName(s)))
+			}
+		}
+	}
+
+	checked := map[State]bool{}
+	for _, from := range sm.sources() {
+		for _, t := range sm.Transitions[sm.key(from)] {
+			if checked[sm.key(t.To)] {
+				continue
+			}
+			checked[sm.key(t.To)] = true
+			if len(sm.outgoing(t.To)) == 0 && !sm.hasActions(t.To) {
+				problems = append(problems, fmt.Errorf("%w: %s is a transition target with no outgoing transitions or actions (possible typo)", ErrInvalidDefinition, stateName(t.To)))
+			}
+		}
+	}
+
+	if len(sm.outgoing(sm.InitialState)) == 0 {
+		problems = append(problems, fmt.Errorf("%w: initial state %s has no outgoing transitions", ErrInvalidDefinition, stateName(sm.InitialState)))
+	}
+
+	for _, s := range sm.inOrder(sm.nonTerminal) {
+		if len(sm.outgoing(s)) == 0 {
+			problems = append(problems, fmt.Errorf("%w: %s must not be terminal but has no outgoing transitions", ErrInvalidDefinition, stateName(s)))
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// mark states that must always have a way out, e.g. "processing" states that would strand a workflow if
+// they became dead ends. Validate reports an error for each marked state with no outgoing transitions
+func (sm *StateMachine) RequireNonTerminal(states ...State) {
+	for _, s := range states {
+		sm.nonTerminal[sm.key(s)] = true
+	}
+}
+
+// report whether any kind of action is registered for a state
+func (sm *StateMachine) hasActions(s State) bool {
+	k := sm.key(s)
+	return sm.entryActions[k] != nil || sm.postEntryActions[k] != nil || sm.exitActions[k] != nil ||
+		sm.twoPhaseActions[k] != nil || sm.hasEntryActionsFrom(s)
+}
@@ -0,0 +1,97 @@
+package statemachine_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+type lightState string
+
+func TestTypeConsistency(t *testing.T) {
+	sm := statemachine.NewStateMachine("Off")
+	sm.AddSimpleTransition("Off", "On")
+	if err := sm.TypeConsistency(); err != nil {
+		t.Fatalf("TypeConsistency on plain strings: %v", err)
+	}
+
+	sm.AddSimpleTransition("On", lightState("Off"))
+	err := sm.TypeConsistency()
+	if !errors.Is(err, statemachine.ErrInvalidDefinition) || !containsAll(err.Error(), "string (e.g. Off)", "statemachine_test.lightState (e.g. Off)") {
+		t.Fatalf("TypeConsistency = %v, want both types listed", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	noop := func() error { return nil }
+
+	tests := []struct {
+		name  string
+		setup func(sm *statemachine.StateMachine)
+		want  []string
+	}{
+		{
+			name: "well-formed",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddBidirectional("Off", "On")
+				sm.SetEntryAction("On", noop)
+			},
+		},
+		{
+			name: "action for an unreferenced state",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddBidirectional("Off", "On")
+				sm.SetExitAction("Of", noop)
+			},
+			want: []string{"exit action registered for Of"},
+		},
+		{
+			name: "dead-end target without actions",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddBidirectional("Off", "On")
+				sm.AddSimpleTransition("On", "Of")
+			},
+			want: []string{"Of is a transition target with no outgoing transitions or actions"},
+		},
+		{
+			name: "initial state without transitions",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("On", "Off")
+				sm.SetEntryAction("Off", noop)
+			},
+			want: []string{"initial state Off has no outgoing transitions"},
+		},
+		{
+			name: "state required not to be terminal",
+			setup: func(sm *statemachine.StateMachine) {
+				sm.AddSimpleTransition("Off", "On")
+				sm.SetEntryAction("On", noop)
+				sm.RequireNonTerminal("On")
+			},
+			want: []string{"On must not be terminal"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Off")
+			tt.setup(sm)
+
+			err := sm.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, statemachine.ErrInvalidDefinition) {
+				t.Fatalf("Validate error = %v, want ErrInvalidDefinition", err)
+			}
+			if problems := strings.Split(err.Error(), "\n"); len(problems) != len(tt.want) || !containsAll(err.Error(), tt.want...) {
+				t.Fatalf("Validate = %v, want exactly %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package statemachine
+
+// how many times the machine has entered a state. the initial state counts as visited once as soon
+// as the machine is created, since the machine starts out in it. counts survive `Reset`, which doesn't
+// enter the initial state so much as jump to it, and are only cleared by `ResetCounts` or `ResetAll`
+func (sm *StateMachine) VisitCount(state State) int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.visits[sm.key(state)]
+}
+
+// a copy of the visit count of every state that has been entered at least once, see VisitCount
+func (sm *StateMachine) AllVisitCounts() map[State]int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	counts := make(map[State]int, len(sm.visits))
+	for s, n := range sm.visits {
+		counts[s] = n
+	}
+	return counts
+}
+
+// clear every visit count, including the one the initial state starts out with
+func (sm *StateMachine) ResetCounts() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.visits = make(map[State]int)
+}
+
+// count an entry into each of the given states. when a transition enters a substate from outside its
+// parent, the parent counts as visited as well
+func (sm *StateMachine) recordVisits(entered []State) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for _, s := range entered {
+		sm.visits[sm.key(s)]++
+	}
+}
@@ -0,0 +1,28 @@
+package statemachine
+
+import "context"
+
+// block until the machine is in the target state, returning nil straight away if it already is. the
+// wait ends with the context's error if the context is cancelled or times out first. only transitions
+// wake the wait, so a machine moved into the target by Reset isn't noticed until it next transitions
+func (sm *StateMachine) WaitForState(ctx context.Context, target State) error {
+	// subscribe before looking at the current state, so a transition landing in between isn't missed
+	changes := sm.Subscribe()
+	defer sm.Unsubscribe(changes)
+
+	if sm.sameState(sm.current(), target) {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case change := <-changes:
+			// subscribers can miss changes when they fall behind, so the current state is checked as well
+			if sm.sameState(change.To, target) || sm.sameState(sm.current(), target) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package statemachine
+
+// a dedicated type for the wildcard so it can never collide with a user's own state values
+type anyState struct{}
+
+func (anyState) String() string { return "*" }
+
+// AnyState is a wildcard source state. A transition registered from AnyState, e.g.
+// `AddTransition(AnyState, Cancelled, nil, nil)`, applies to every current state. Transitions
+// registered on the current state itself are always matched first, so they take precedence over
+// a wildcard transition to the same target.
+var AnyState State = anyState{}
@@ -0,0 +1,45 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	statemachine "github.com/jwald3/lollipop"
+)
+
+func TestWildcardTransitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    []statemachine.State
+		to      statemachine.State
+		wantErr error
+		want    statemachine.State
+	}{
+		{name: "from any state", path: []statemachine.State{"Paid"}, to: "Cancelled", want: "Cancelled"},
+		{name: "from the initial state", to: "Cancelled", want: "Cancelled"},
+		{name: "own transition wins", path: []statemachine.State{"Paid", "Shipped"}, to: "Cancelled", wantErr: statemachine.ErrGuardFailed, want: "Shipped"},
+		{name: "not into itself", path: []statemachine.State{"Cancelled"}, to: "Cancelled", wantErr: statemachine.ErrSelfTransition, want: "Cancelled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := statemachine.NewStateMachine("Created")
+			sm.AddTransitions("Created", "Paid")
+			sm.AddTransitions("Paid", "Shipped")
+			sm.AddTransition("Shipped", "Cancelled", func() bool { return false }, nil)
+			sm.AddTransition(statemachine.AnyState, "Cancelled", nil, nil)
+			for _, s := range tt.path {
+				if err := sm.Transition(s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := sm.Transition(tt.to); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition error = %v, want %v", err, tt.wantErr)
+			}
+			if got := sm.CurrentState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}